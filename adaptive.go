@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	// keys need this many presses before their stats are trusted
+	MIN_KEY_PRESSES = 10
+	TARGET_LETTERS  = 4
+	TARGET_BIGRAMS  = 4
+	// how much more likely a word is picked for every target it contains
+	TARGET_WEIGHT = 4.0
+	// an error rate of 10% weighs as much as being twice as slow as average
+	ERROR_WEIGHT = 10.0
+)
+
+// Target is a letter or bigram the practice test focuses on.
+type Target struct {
+	key   string
+	stat  KeyStat
+	score float64
+}
+
+func (t Target) Describe() string {
+	return fmt.Sprintf("%s: %.0f%% errors, %.0fms", t.key, t.stat.ErrorRate()*100, t.stat.AvgLatency())
+}
+
+// weakTargets ranks the letters and bigrams by how slow and error prone
+// they are and returns the worst of each.
+func weakTargets(stats KeyStats) []Target {
+	letters := rankTargets(stats.Keys(), TARGET_LETTERS)
+	bigrams := rankTargets(stats.Bigrams(), TARGET_BIGRAMS)
+
+	return append(letters, bigrams...)
+}
+
+func rankTargets(stats KeyStats, n int) []Target {
+	totalLatency, timed := 0.0, 0
+	for _, stat := range stats {
+		totalLatency += stat.LatencyMs
+		timed += stat.Timed
+	}
+	if timed == 0 {
+		return nil
+	}
+	avgLatency := totalLatency / float64(timed)
+
+	var targets []Target
+	for key, stat := range stats {
+		if stat.Presses() < MIN_KEY_PRESSES || strings.ContainsAny(key, " ") {
+			continue
+		}
+		score := stat.ErrorRate() * ERROR_WEIGHT
+		if stat.Timed > 0 {
+			score += stat.AvgLatency() / avgLatency
+		}
+		targets = append(targets, Target{key, *stat, score})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].score > targets[j].score
+	})
+	if len(targets) > n {
+		targets = targets[:n]
+	}
+
+	return targets
+}
+
+// generatePracticeWords picks words at random, favoring the ones that
+// contain the user's weakest letters and bigrams.
+func generatePracticeWords(r *rand.Rand, conf Config, targets []Target) string {
+	words := strings.Fields(words)
+	weights := make([]float64, len(words))
+	total := 0.0
+	for i, word := range words {
+		weights[i] = 1
+		for _, t := range targets {
+			weights[i] += float64(strings.Count(word, t.key)) * TARGET_WEIGHT
+		}
+		total += weights[i]
+	}
+
+	selectedWords := make([]string, conf.Words)
+	for i := range selectedWords {
+		pick := r.Float64() * total
+		j := 0
+		for ; j < len(words)-1 && pick >= weights[j]; j++ {
+			pick -= weights[j]
+		}
+		selectedWords[i] = words[j]
+	}
+
+	return strings.Join(selectedWords, " ")
+}
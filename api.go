@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	API_ADDR = "127.0.0.1:7879"
+	// results returned by /results unless a limit is asked for, and the most
+	// it returns at once
+	API_RESULTS     = 20
+	API_MAX_RESULTS = 1000
+)
+
+// apiCommand runs `monkeytype api`, serving the history and stats the app
+// saved as JSON. They're read again on every request, so results show up
+// as soon as a test is finished.
+func apiCommand(args []string) error {
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	addr := flags.String("listen", API_ADDR, "address to serve the api on")
+	_ = flags.Parse(args)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /results", handleResults)
+	mux.HandleFunc("GET /bests", handleBests)
+	mux.HandleFunc("GET /keys", handleKeys)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("serving the api on http://%s", *addr)
+	return server.ListenAndServe()
+}
+
+// handleResults lists the latest results, newest first. `?limit=` changes
+// how many, up to API_MAX_RESULTS.
+func handleResults(w http.ResponseWriter, r *http.Request) {
+	limit := API_RESULTS
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		limit = min(n, API_MAX_RESULTS)
+	}
+
+	entries, err := LoadHistory(LocalStore)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := make([]HistoryEntry, 0, min(limit, len(entries)))
+	for i := len(entries) - 1; i >= 0 && len(results) < limit; i-- {
+		results = append(results, entries[i])
+	}
+	writeJSON(w, results)
+}
+
+// PersonalBest is the fastest finished test of a mode.
+type PersonalBest struct {
+	Mode        string    `json:"mode"`
+	Date        time.Time `json:"date"`
+	Wpm         int       `json:"wpm"`
+	RawWpm      int       `json:"raw_wpm"`
+	Accuracy    int       `json:"accuracy"`
+	Consistency int       `json:"consistency"`
+}
+
+// handleBests lists the personal best of every time, words and quote mode
+// tests were finished in.
+func handleBests(w http.ResponseWriter, r *http.Request) {
+	entries, err := LoadHistory(LocalStore)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	bests := map[string]PersonalBest{}
+	for _, e := range entries {
+		mode := testMode(e.Kind, e.Config, e.GivenText)
+		if mode == "" || e.Failed {
+			continue
+		}
+		if best, ok := bests[mode]; ok && best.Wpm >= e.Wpm {
+			continue
+		}
+		bests[mode] = PersonalBest{
+			Mode:        mode,
+			Date:        e.Date,
+			Wpm:         e.Wpm,
+			RawWpm:      e.RawWpm,
+			Accuracy:    e.Accuracy,
+			Consistency: e.Consistency,
+		}
+	}
+
+	list := make([]PersonalBest, 0, len(bests))
+	for _, best := range bests {
+		list = append(list, best)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Mode < list[j].Mode
+	})
+	writeJSON(w, list)
+}
+
+// KeyReport is what /keys returns about a single key or bigram.
+type KeyReport struct {
+	Presses      int     `json:"presses"`
+	Mistakes     int     `json:"mistakes"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// handleKeys returns the stats of every key and bigram typed so far.
+func handleKeys(w http.ResponseWriter, r *http.Request) {
+	stats, err := LoadKeyStats(LocalStore)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	report := func(stats KeyStats) map[string]KeyReport {
+		reports := make(map[string]KeyReport, len(stats))
+		for key, stat := range stats {
+			reports[key] = KeyReport{
+				Presses:      stat.Presses(),
+				Mistakes:     stat.Mistakes,
+				ErrorRate:    stat.ErrorRate(),
+				AvgLatencyMs: stat.AvgLatency(),
+			}
+		}
+		return reports
+	}
+
+	writeJSON(w, map[string]map[string]KeyReport{
+		"keys":    report(stats.Keys()),
+		"bigrams": report(stats.Bigrams()),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	// words are typed this much faster or slower than the profile's speed,
+	// as a standard deviation
+	BOT_WORD_VARIANCE = 0.15
+	BOT_KEY_VARIANCE  = 0.3
+	// noticing and fixing a mistake costs a backspace, the retyped key and
+	// this long
+	BOT_REACTION = 250 * time.Millisecond
+)
+
+// BotProfile describes how a simulated opponent types.
+type BotProfile struct {
+	name     string
+	wpm      float64
+	accuracy float64
+}
+
+var BotProfiles = []BotProfile{
+	{"beginner", 25, 0.90},
+	{"average", 45, 0.94},
+	{"fast", 70, 0.96},
+	{"expert", 100, 0.98},
+}
+
+// bot is an opponent whose whole race is decided upfront: keyAt holds the
+// time each character of the text gets typed correctly.
+type bot struct {
+	profile BotProfile
+	keyAt   []time.Duration
+}
+
+func newBot(r *rand.Rand, profile BotProfile, text string) bot {
+	// the time between keys, chosen so that mistakes included the bot ends up
+	// around the profile's speed
+	mistakes := 1 - profile.accuracy
+	perKey := float64(time.Minute) / (profile.wpm * 5)
+	interval := (perKey - mistakes*float64(BOT_REACTION)) / (1 + 2*mistakes)
+
+	b := bot{profile: profile, keyAt: make([]time.Duration, len(text))}
+	elapsed := 0.0
+	factor := 1.0
+	for i := 0; i < len(text); i++ {
+		// every word gets its own pace, some are just harder than others
+		if i == 0 || text[i-1] == ' ' {
+			factor = clamp(1+r.NormFloat64()*BOT_WORD_VARIANCE, 0.5, 2)
+		}
+		key := interval * factor * clamp(1+r.NormFloat64()*BOT_KEY_VARIANCE, 0.3, 3)
+		if r.Float64() > profile.accuracy {
+			key += 2*interval*factor + float64(BOT_REACTION)
+		}
+		elapsed += key
+		b.keyAt[i] = time.Duration(elapsed)
+	}
+
+	return b
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// typed returns how many characters the bot typed after elapsed.
+func (b bot) typed(elapsed time.Duration) int {
+	n := 0
+	for n < len(b.keyAt) && b.keyAt[n] <= elapsed {
+		n++
+	}
+	return n
+}
+
+func (b bot) finishedAt() time.Duration {
+	return b.keyAt[len(b.keyAt)-1]
+}
+
+var _ Race = (*BotRace)(nil)
+
+// BotRace races the user against bots, all timed by the test's clock so
+// pausing the test pauses them too.
+type BotRace struct {
+	bots  []bot
+	clock *Clock
+
+	finished   bool
+	finishedAt time.Duration
+	wpm        int
+}
+
+func NewBotRace(r *rand.Rand, profiles []int, text string, clock *Clock) *BotRace {
+	race := &BotRace{clock: clock}
+	for _, p := range profiles {
+		race.bots = append(race.bots, newBot(r, BotProfiles[p], text))
+	}
+	return race
+}
+
+// Watch doesn't need to notify anyone, the test redraws the bots at least
+// every tick.
+func (b *BotRace) Watch(view Drawable) {
+}
+
+func (b *BotRace) Report(progress float64, wpm int) {
+}
+
+func (b *BotRace) Finish(wpm int) {
+	b.finished = true
+	b.finishedAt = b.clock.Elapsed()
+	b.wpm = wpm
+}
+
+func (b *BotRace) Leave() {
+}
+
+func (b *BotRace) Lanes() []Lane {
+	return b.lanes(b.clock.Elapsed())
+}
+
+// lanes returns the bots as they are after elapsed, placing the finished
+// ones against each other and the user.
+func (b *BotRace) lanes(elapsed time.Duration) []Lane {
+	lanes := make([]Lane, len(b.bots))
+	for i, bot := range b.bots {
+		typed := bot.typed(elapsed)
+		lane := Lane{
+			ID:       i + 1,
+			Name:     fmt.Sprintf("%s bot", bot.profile.name),
+			Progress: float64(typed) / float64(len(bot.keyAt)),
+		}
+		if elapsed > 0 {
+			lane.Wpm = int(float64(typed) / 5 / elapsed.Minutes())
+		}
+
+		if typed == len(bot.keyAt) {
+			lane.Wpm = int(float64(typed) / 5 / bot.finishedAt().Minutes())
+			lane.Place = 1
+			if b.finished && b.finishedAt < bot.finishedAt() {
+				lane.Place += 1
+			}
+			for _, other := range b.bots {
+				if other.finishedAt() < bot.finishedAt() {
+					lane.Place += 1
+				}
+			}
+		}
+		lanes[i] = lane
+	}
+	return lanes
+}
+
+// Standings plays the race out to the end, the bots are bound to finish.
+func (b *BotRace) Standings() []Lane {
+	end := b.finishedAt
+	for _, bot := range b.bots {
+		if bot.finishedAt() > end {
+			end = bot.finishedAt()
+		}
+	}
+	lanes := b.lanes(end)
+
+	user := Lane{Name: "you", Progress: 1, Wpm: b.wpm, Left: !b.finished}
+	if b.finished {
+		user.Place = 1
+		for _, bot := range b.bots {
+			if bot.finishedAt() <= b.finishedAt {
+				user.Place += 1
+			}
+		}
+	}
+	lanes = append(lanes, user)
+
+	sortStandings(lanes)
+	return lanes
+}
+
+// RacePrompt picks the bots to race against and the length of the text.
+type RacePrompt struct {
+	// the bots on the left, the word counts on the right
+	optionColumns
+}
+
+func NewRacePrompt() *RacePrompt {
+	bots := make([]string, len(BotProfiles))
+	for i, p := range BotProfiles {
+		bots[i] = fmt.Sprintf("%s %.0f wpm", p.name, p.wpm)
+	}
+
+	return &RacePrompt{optionColumns{
+		left:  NewCheckList(bots, 1, 2),
+		right: NewOptionList(WordCountChoices, 1),
+	}}
+}
+
+func (w *RacePrompt) Name() string {
+	return "race"
+}
+
+func (w *RacePrompt) Kind() int {
+	return TEST_RACE
+}
+
+func (w *RacePrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	lineWidth := (boxWidth / 2) + startCol - 1
+	w.left.Draw(screen, startCol-6+(lineWidth-startCol)/2, startRow, 2, w.focused(true))
+	drawDivider(screen, lineWidth, startRow)
+	w.right.Draw(screen, lineWidth+(startCol+boxWidth-lineWidth)/2, startRow, 2, w.focused(false))
+}
+
+func (w *RacePrompt) Config() Config {
+	wc, _ := strconv.Atoi(WordCountChoices[w.right.selected])
+	bots := w.left.checked
+	if len(bots) == 0 {
+		bots = []int{1}
+	}
+	return Config{
+		Words: wc,
+		Bots:  bots,
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import "github.com/gdamore/tcell/v2"
+
+const (
+	CARET_OFF int = iota
+	CARET_BLOCK
+	CARET_LINE
+	CARET_UNDERLINE
+)
+
+const (
+	CARET_MODE_CURSOR int = iota
+	CARET_MODE_CELL
+)
+
+var (
+	CaretStyles = []string{"off", "block", "line", "underline"}
+	CaretModes  = []string{"cursor", "cell"}
+)
+
+// drawCaret shows the caret at the given position, either by moving the
+// terminal cursor there or by restyling the cell underneath it.
+func drawCaret(screen tcell.Screen, x, y int) {
+	prefs := prefsOf(screen)
+	if prefs.CaretStyle == CARET_OFF {
+		screen.HideCursor()
+		return
+	}
+
+	if prefs.CaretMode == CARET_MODE_CURSOR {
+		switch prefs.CaretStyle {
+		case CARET_BLOCK:
+			screen.SetCursorStyle(tcell.CursorStyleSteadyBlock)
+		case CARET_LINE:
+			screen.SetCursorStyle(tcell.CursorStyleSteadyBar)
+		case CARET_UNDERLINE:
+			screen.SetCursorStyle(tcell.CursorStyleSteadyUnderline)
+		}
+		screen.ShowCursor(x, y)
+		return
+	}
+
+	screen.HideCursor()
+	ch, combc, style, _ := screen.GetContent(x, y)
+	switch prefs.CaretStyle {
+	case CARET_BLOCK:
+		style = style.Reverse(true)
+	case CARET_LINE:
+		// a cell can't hold both a bar and a character, so the bar is only
+		// drawn over blanks and the character is highlighted otherwise
+		if ch == ' ' {
+			ch = '▏'
+		}
+		_, bg, _ := style.Decompose()
+		style = CaretTextStyle.Background(bg)
+	case CARET_UNDERLINE:
+		style = style.Underline(true)
+	}
+	screen.SetContent(x, y, ch, combc, style)
+}
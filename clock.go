@@ -0,0 +1,62 @@
+package main
+
+import "time"
+
+// Clock measures how long a test has been running, leaving out the time
+// it spent paused.
+type Clock struct {
+	start    time.Time
+	pausedAt time.Time
+	paused   time.Duration
+}
+
+func (c *Clock) Start() {
+	if !c.Started() {
+		c.start = time.Now()
+	}
+}
+
+func (c *Clock) Started() bool {
+	return c.start != time.Time{}
+}
+
+func (c *Clock) IsPaused() bool {
+	return c.pausedAt != time.Time{}
+}
+
+func (c *Clock) Pause() {
+	if c.Started() && !c.IsPaused() {
+		c.pausedAt = time.Now()
+	}
+}
+
+func (c *Clock) Resume() {
+	if c.IsPaused() {
+		c.paused += time.Since(c.pausedAt)
+		c.pausedAt = time.Time{}
+	}
+}
+
+// Elapsed returns the running time without pauses.
+func (c *Clock) Elapsed() time.Duration {
+	if !c.Started() {
+		return 0
+	}
+	return c.Wall() - c.Paused()
+}
+
+// Wall returns the time since the clock started, pauses included.
+func (c *Clock) Wall() time.Duration {
+	if !c.Started() {
+		return 0
+	}
+	return time.Since(c.start)
+}
+
+// Paused returns the total time spent paused so far.
+func (c *Clock) Paused() time.Duration {
+	if c.IsPaused() {
+		return c.paused + time.Since(c.pausedAt)
+	}
+	return c.paused
+}
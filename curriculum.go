@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	CURRICULUM_FILE = "curriculum.json"
+	LESSON_WORDS    = 30
+)
+
+// CurriculumLesson is a single step of the bundled touch typing course.
+// It passes when a test of its text is typed at wpm and accuracy.
+type CurriculumLesson struct {
+	name     string
+	generate func(r *rand.Rand, n int) []string
+	wpm      int
+	accuracy int
+}
+
+var Curriculum = []CurriculumLesson{
+	{"home row", func(r *rand.Rand, n int) []string { return lettersWords(r, "asdfghjkl", n) }, 20, 95},
+	{"top row", func(r *rand.Rand, n int) []string { return lettersWords(r, "qwertyuiop", n) }, 20, 95},
+	{"bottom row", func(r *rand.Rand, n int) []string { return lettersWords(r, "zxcvbnm", n) }, 15, 95},
+	{"home and top rows", func(r *rand.Rand, n int) []string { return lettersWords(r, "asdfghjklqwertyuiop", n) }, 25, 95},
+	{"all letters", func(r *rand.Rand, n int) []string { return lettersWords(r, "abcdefghijklmnopqrstuvwxyz", n) }, 30, 95},
+	{"numbers", numberWords, 20, 90},
+	{"symbols", symbolWords, 15, 90},
+	{"capitals", capitalWords, 25, 95},
+	{"common bigrams", bigramWords, 30, 95},
+}
+
+var (
+	CommonBigrams = []string{"th", "he", "in", "er", "an", "re", "on", "at", "en", "nd", "ti", "es", "or", "te", "of"}
+	LessonSymbols = []string{"!", "?", ".", ",", ";", ":", "'", "-", "(", ")", "[", "]", "{", "}", "@", "#", "$", "%", "&", "*", "+", "=", "/"}
+)
+
+// lettersWords picks real words made of the given letters, making up
+// pseudo words when there aren't enough of them.
+func lettersWords(r *rand.Rand, letters string, n int) []string {
+	pool := filterWords(strings.Fields(words), letters)
+
+	selected := make([]string, n)
+	for i := range selected {
+		if len(pool) >= MIN_LESSON_WORDS || (len(pool) > 0 && r.Intn(2) == 0) {
+			selected[i] = pool[r.Intn(len(pool))]
+		} else {
+			selected[i] = pseudoWord(r, letters, "")
+		}
+	}
+	return selected
+}
+
+func numberWords(r *rand.Rand, n int) []string {
+	selected := make([]string, n)
+	for i := range selected {
+		selected[i] = fmt.Sprintf("%d", r.Intn(MAX_NUM))
+	}
+	return selected
+}
+
+func symbolWords(r *rand.Rand, n int) []string {
+	selected := lettersWords(r, "abcdefghijklmnopqrstuvwxyz", n)
+	for i := range selected {
+		symbol := LessonSymbols[r.Intn(len(LessonSymbols))]
+		if r.Intn(2) == 0 {
+			selected[i] = symbol + selected[i]
+		} else {
+			selected[i] += symbol
+		}
+	}
+	return selected
+}
+
+func capitalWords(r *rand.Rand, n int) []string {
+	selected := lettersWords(r, "abcdefghijklmnopqrstuvwxyz", n)
+	for i, w := range selected {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		selected[i] = string(runes)
+	}
+	return selected
+}
+
+func bigramWords(r *rand.Rand, n int) []string {
+	var pool []string
+	for _, w := range strings.Fields(words) {
+		for _, b := range CommonBigrams {
+			if strings.Contains(w, b) {
+				pool = append(pool, w)
+				break
+			}
+		}
+	}
+
+	selected := make([]string, n)
+	for i := range selected {
+		selected[i] = pool[r.Intn(len(pool))]
+	}
+	return selected
+}
+
+func generateCurriculumLesson(r *rand.Rand, conf Config) string {
+	return strings.Join(Curriculum[conf.Lesson].generate(r, conf.Words), " ")
+}
+
+// CurriculumProgress holds the names of the passed lessons.
+type CurriculumProgress map[string]bool
+
+func LoadCurriculumProgress(store Store) CurriculumProgress {
+	progress := CurriculumProgress{}
+
+	path, err := store.path(CURRICULUM_FILE)
+	if err != nil {
+		return progress
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return progress
+	}
+	_ = json.Unmarshal(content, &progress)
+
+	return progress
+}
+
+func (c CurriculumProgress) Save(store Store) error {
+	path, err := store.path(CURRICULUM_FILE)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+var _ Drawable = (*Lessons)(nil)
+
+// Lessons lists the curriculum, marking the passed lessons, and starts the
+// selected one as a test.
+type Lessons struct {
+	screen   tcell.Screen
+	progress CurriculumProgress
+	curr     int
+}
+
+func NewLessons(screen tcell.Screen) Drawable {
+	return &Lessons{
+		screen: screen,
+	}
+}
+
+func (l *Lessons) Init() {
+	l.progress = LoadCurriculumProgress(storeOf(l.screen))
+}
+
+func (l *Lessons) Draw() {
+	title := "lessons"
+	drawTextCentered(l.screen, len(title), 1, title, AppYellowTextStyle)
+
+	sWidth, sHeight := l.screen.Size()
+	boxWidth := sWidth / 2
+	startW := (sWidth - boxWidth) / 2
+
+	for i, lesson := range Curriculum {
+		mark := "[ ]"
+		if l.progress[lesson.name] {
+			mark = "[X]"
+		}
+		name := fmt.Sprintf("  %s %s", mark, lesson.name)
+		style := AppTextStyle
+		if i == l.curr {
+			name = fmt.Sprintf("%c %s %s", tcell.RuneDiamond, mark, lesson.name)
+			style = AppYellowTextStyle
+		}
+		goal := fmt.Sprintf("%d wpm, %d%%", lesson.wpm, lesson.accuracy)
+
+		drawText(l.screen, len(name), startW, 3+i*2, name, style)
+		drawText(l.screen, len(goal), startW+boxWidth-len(goal), 3+i*2, goal, style)
+	}
+
+	help := "up/down to move, enter to start the lesson, l to go back..."
+	drawTextCentered(l.screen, len(help), sHeight-2, help, AppTextStyle)
+}
+
+func (l *Lessons) Update(event tcell.Event) Drawable {
+	k := event.(*tcell.EventKey)
+	switch k.Key() {
+	case tcell.KeyUp:
+		if l.curr > 0 {
+			l.curr -= 1
+		} else {
+			l.curr = len(Curriculum) - 1
+		}
+	case tcell.KeyDown:
+		if l.curr < len(Curriculum)-1 {
+			l.curr += 1
+		} else {
+			l.curr = 0
+		}
+	case tcell.KeyEnter:
+		return NewTest(l.screen, TEST_CURRICULUM, Config{Words: LESSON_WORDS, Lesson: l.curr})
+	case tcell.KeyRune:
+		if k.Rune() == 'l' {
+			return NewMenu(l.screen)
+		}
+	}
+
+	return nil
+}
@@ -7,3 +7,9 @@ type Drawable interface {
 	Draw()
 	Update(tcell.Event) (next Drawable)
 }
+
+// TextInput is implemented by drawables that are being typed into, where
+// q is just a letter rather than a way to quit.
+type TextInput interface {
+	TakesText() bool
+}
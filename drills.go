@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Drill picks the keys of a hand, row or finger by their position on the
+// keyboard, so the same drill follows whichever layout is in use.
+type Drill struct {
+	group string
+	name  string
+	on    func(row, col int) bool
+}
+
+var (
+	DrillGroups = []string{"hand", "row", "finger"}
+	Fingers     = []string{
+		"left pinky", "left ring", "left middle", "left index",
+		"right index", "right middle", "right ring", "right pinky",
+	}
+	// the finger pressing each column of keys when touch typing, as an
+	// index in Fingers
+	FingerColumns = []int{0, 1, 2, 3, 3, 4, 4, 5, 6, 7, 7, 7}
+
+	Drills = drills()
+)
+
+func drills() []Drill {
+	list := []Drill{
+		{"hand", "left hand", func(row, col int) bool { return col < 5 }},
+		{"hand", "right hand", func(row, col int) bool { return col >= 5 }},
+		{"row", "top row", func(row, col int) bool { return row == 1 }},
+		{"row", "home row", func(row, col int) bool { return row == 2 }},
+		{"row", "bottom row", func(row, col int) bool { return row == 3 }},
+	}
+	for i, name := range Fingers {
+		finger := i
+		list = append(list, Drill{"finger", name, func(row, col int) bool { return FingerColumns[col] == finger }})
+	}
+	return list
+}
+
+// Keys returns the letters the drill covers on the layout. The number row
+// is left out, drills are made of words.
+func (d Drill) Keys(l Layout) string {
+	var keys strings.Builder
+	for row := 1; row < len(l.Rows); row++ {
+		for col, ch := range []rune(l.Rows[row]) {
+			if d.on(row, col) && unicode.IsLetter(ch) {
+				keys.WriteRune(ch)
+			}
+		}
+	}
+	return keys.String()
+}
+
+// drillsIn returns the indexes in Drills of the group's drills.
+func drillsIn(group string) []int {
+	var indexes []int
+	for i, d := range Drills {
+		if d.group == group {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// generateDrillWords builds a text out of the drill's keys on the layout,
+// falling back to every letter when the layout has none there.
+func generateDrillWords(r *rand.Rand, conf Config, layout Layout) string {
+	keys := Drills[conf.Drill].Keys(layout)
+	if keys == "" {
+		keys = "abcdefghijklmnopqrstuvwxyz"
+	}
+
+	return strings.Join(lettersWords(r, keys, conf.Words), " ")
+}
+
+// DrillPrompt picks a hand, row or finger to drill on.
+type DrillPrompt struct {
+	drill int
+
+	// the groups on the left, the drills of the current one on the right
+	optionColumns
+}
+
+func NewDrillPrompt() *DrillPrompt {
+	w := &DrillPrompt{optionColumns: optionColumns{
+		left: NewOptionList(DrillGroups, 0),
+	}}
+	w.listDrills()
+	return w
+}
+
+func (w *DrillPrompt) Name() string {
+	return "drill"
+}
+
+func (w *DrillPrompt) Kind() int {
+	return TEST_DRILL
+}
+
+// listDrills lists the drills of the current group on the right, marking
+// the picked drill if it's one of them.
+func (w *DrillPrompt) listDrills() {
+	drills := drillsIn(DrillGroups[w.left.curr])
+	names := make([]string, len(drills))
+	w.right = NewOptionList(names, -1)
+	w.right.curr = 0
+	for i, d := range drills {
+		names[i] = Drills[d].name
+		if d == w.drill {
+			w.right.selected = i
+		}
+	}
+}
+
+func (w *DrillPrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	lineWidth := (boxWidth / 2) + startCol - 1
+	w.left.Draw(screen, startCol-3+(lineWidth-startCol)/2, startRow, 2, w.focused(true))
+	drawDivider(screen, lineWidth, startRow)
+	w.right.Draw(screen, lineWidth+2+(startCol+boxWidth-lineWidth)/4, startRow, 1, w.focused(false))
+
+	layout := currentLayout(prefsOf(screen))
+	keys := fmt.Sprintf("%s on %s: %s", Drills[w.drill].name, layout.Name, Drills[w.drill].Keys(layout))
+	drawTextCentered(screen, len(keys), startRow+9, keys, AppTextStyle)
+}
+
+func (w *DrillPrompt) Update(event *tcell.EventKey) bool {
+	group := w.left.curr
+	if w.optionColumns.Update(event) {
+		return true
+	}
+
+	// moving through the groups shows their drills right away
+	if w.left.curr != group {
+		w.left.selected = w.left.curr
+		w.listDrills()
+	}
+	if w.right.selected >= 0 {
+		w.drill = drillsIn(DrillGroups[w.left.curr])[w.right.selected]
+	}
+	return false
+}
+
+func (w *DrillPrompt) Config() Config {
+	return Config{
+		Words: LESSON_WORDS,
+		Drill: w.drill,
+	}
+}
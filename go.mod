@@ -2,14 +2,19 @@ module github.com/ZutrixPog/monkeytype
 
 go 1.22.0
 
-require github.com/gdamore/tcell/v2 v2.7.1
+require (
+	github.com/gdamore/tcell/v2 v2.7.1
+	github.com/gliderlabs/ssh v0.3.8
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0
+)
 
 require (
+	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
-	golang.org/x/sys v0.17.0 // indirect
-	golang.org/x/term v0.17.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
+	golang.org/x/term v0.27.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 )
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const (
+	HEATMAP_ERRORS int = iota
+	HEATMAP_SPEED
+)
+
+var (
+	HeatmapModes = []string{"error rate", "speed"}
+	QwertyRows   = []string{"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}
+
+	// from cold to hot
+	HeatColors = []tcell.Color{
+		tcell.Color28, tcell.Color70, tcell.Color142,
+		tcell.Color178, tcell.Color166, tcell.Color160,
+	}
+)
+
+var _ Drawable = (*Heatmap)(nil)
+
+// Heatmap draws a keyboard with each key colored by how often it's mistyped
+// or how long it takes to press, based on the saved key stats.
+type Heatmap struct {
+	screen tcell.Screen
+	stats  KeyStats
+	mode   int
+}
+
+func NewHeatmap(screen tcell.Screen) Drawable {
+	return &Heatmap{
+		screen: screen,
+	}
+}
+
+func (h *Heatmap) Init() {
+	stats, _ := LoadKeyStats(storeOf(h.screen))
+	h.stats = stats.Keys()
+}
+
+func (h *Heatmap) value(stat *KeyStat) float64 {
+	if h.mode == HEATMAP_SPEED {
+		return stat.AvgLatency()
+	}
+	return stat.ErrorRate()
+}
+
+func (h *Heatmap) format(stat *KeyStat) string {
+	if h.mode == HEATMAP_SPEED {
+		return fmt.Sprintf("%.0fms", stat.AvgLatency())
+	}
+	return fmt.Sprintf("%.0f%%", stat.ErrorRate()*100)
+}
+
+func (h *Heatmap) Draw() {
+	// stats are kept by the character typed, so keys are drawn where the
+	// emulated layout has them
+	layout := currentLayout(prefsOf(h.screen))
+	title := fmt.Sprintf("key heatmap: %s on %s", HeatmapModes[h.mode], layout.Name)
+	drawTextCentered(h.screen, len(title), 2, title, AppYellowTextStyle)
+
+	// keys are colored relative to the best and worst measured key
+	lo, hi := -1.0, 0.0
+	for _, stat := range h.stats {
+		if stat.Presses() == 0 || (h.mode == HEATMAP_SPEED && stat.Timed == 0) {
+			continue
+		}
+		v := h.value(stat)
+		if lo < 0 || v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+
+	colorOf := func(key string) tcell.Style {
+		stat, ok := h.stats[key]
+		if !ok || stat.Presses() == 0 || (h.mode == HEATMAP_SPEED && stat.Timed == 0) {
+			return BoxStyle
+		}
+		level := 0
+		if hi > lo {
+			level = int((h.value(stat) - lo) / (hi - lo) * float64(len(HeatColors)-1))
+		}
+		return tcell.StyleDefault.Background(HeatColors[level]).Foreground(tcell.ColorWhite)
+	}
+
+	startH := 5
+	keyWidth := 4
+	for i, row := range layout.Rows {
+		startW := centerWidth(h.screen, len(QwertyRows[0])*keyWidth) + i*2
+		for j, ch := range []rune(row) {
+			style := colorOf(string(ch))
+			for k := 0; k < keyWidth-1; k++ {
+				h.screen.SetContent(startW+j*keyWidth+k, startH+i*2, ' ', nil, style)
+			}
+			h.screen.SetContent(startW+j*keyWidth+1, startH+i*2, ch, nil, style)
+		}
+	}
+
+	spaceStyle := colorOf(" ")
+	spaceWidth := 6 * keyWidth
+	spaceW := centerWidth(h.screen, spaceWidth)
+	for k := 0; k < spaceWidth; k++ {
+		h.screen.SetContent(spaceW+k, startH+len(layout.Rows)*2, ' ', nil, spaceStyle)
+	}
+
+	legendH := startH + len(layout.Rows)*2 + 2
+	legendW := centerWidth(h.screen, len(HeatColors)*3+10)
+	drawText(h.screen, 4, legendW, legendH, "good", AppTextStyle)
+	for i, c := range HeatColors {
+		for k := 0; k < 3; k++ {
+			h.screen.SetContent(legendW+5+i*3+k, legendH, ' ', nil, tcell.StyleDefault.Background(c))
+		}
+	}
+	drawText(h.screen, 3, legendW+6+len(HeatColors)*3, legendH, "bad", AppTextStyle)
+
+	worst := h.worstKeys(5)
+	summary := "no key stats yet, take a few tests first..."
+	if len(worst) > 0 {
+		summary = "worst keys: " + strings.Join(worst, ", ")
+	}
+	drawTextCentered(h.screen, len(summary), legendH+2, summary, AppTextStyle)
+
+	_, sHeight := h.screen.Size()
+	help := "left/right to switch between error rate and speed, enter to go back..."
+	drawTextCentered(h.screen, len(help), sHeight-2, help, AppTextStyle)
+}
+
+// worstKeys lists up to n keys with the highest error rate or latency.
+func (h *Heatmap) worstKeys(n int) []string {
+	var keys []string
+	for key, stat := range h.stats {
+		if stat.Presses() > 0 && h.value(stat) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return h.value(h.stats[keys[i]]) > h.value(h.stats[keys[j]])
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+
+	for i, key := range keys {
+		name := key
+		if key == " " {
+			name = "space"
+		}
+		keys[i] = fmt.Sprintf("%s %s", name, h.format(h.stats[key]))
+	}
+	return keys
+}
+
+func (h *Heatmap) Update(event tcell.Event) Drawable {
+	k := event.(*tcell.EventKey)
+	switch k.Key() {
+	case tcell.KeyLeft, tcell.KeyRight:
+		h.mode = (h.mode + 1) % len(HeatmapModes)
+	case tcell.KeyEnter:
+		return NewMenu(h.screen)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+const HISTORY_FILE = "history.jsonl"
+
+// HistoryEntry is a single finished or failed test as saved to the history
+// file, one JSON object per line.
+type HistoryEntry struct {
+	Date     time.Time `json:"date"`
+	Kind     int       `json:"kind"`
+	Config   Config    `json:"config"`
+	Seed     int64     `json:"seed,omitempty"`
+	Wpm      int       `json:"wpm"`
+	RawWpm   int       `json:"raw_wpm"`
+	Accuracy int       `json:"accuracy"`
+	Seconds  float64   `json:"seconds"`
+
+	Consistency int `json:"consistency"`
+	// the text was given rather than generated, like an imported test's
+	GivenText bool `json:"given_text,omitempty"`
+
+	Difficulty  int  `json:"difficulty"`
+	StopOnError int  `json:"stop_on_error"`
+	StrictSpace bool `json:"strict_space"`
+
+	Missed []string `json:"missed,omitempty"`
+	Slow   []string `json:"slow,omitempty"`
+
+	Failed     bool   `json:"failed,omitempty"`
+	FailReason string `json:"fail_reason,omitempty"`
+}
+
+func AppendHistory(store Store, entry HistoryEntry) error {
+	path, err := store.path(HISTORY_FILE)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(content, '\n'))
+	return err
+}
+
+// LoadHistory returns every saved entry, oldest first. Lines that can't be
+// parsed are skipped.
+func LoadHistory(store Store) ([]HistoryEntry, error) {
+	path, err := store.path(HISTORY_FILE)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
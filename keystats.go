@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const KEYSTATS_FILE = "keystats.json"
+
+// KeyStat accumulates the presses of a single character, or of the second
+// character of a bigram, across tests.
+// Latency is only measured for correct presses that followed another key.
+type KeyStat struct {
+	Correct   int     `json:"correct"`
+	Mistakes  int     `json:"mistakes"`
+	Timed     int     `json:"timed"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+func (k KeyStat) Presses() int {
+	return k.Correct + k.Mistakes
+}
+
+func (k KeyStat) ErrorRate() float64 {
+	if k.Presses() == 0 {
+		return 0
+	}
+	return float64(k.Mistakes) / float64(k.Presses())
+}
+
+// AvgLatency returns the average time, in milliseconds, it took to press
+// the key correctly.
+func (k KeyStat) AvgLatency() float64 {
+	if k.Timed == 0 {
+		return 0
+	}
+	return k.LatencyMs / float64(k.Timed)
+}
+
+// KeyStats maps characters and bigrams to their stats.
+type KeyStats map[string]*KeyStat
+
+// Record counts a press of the character or bigram the text expected, a
+// zero latency meaning it wasn't measured. Keys share a stat regardless of
+// case.
+func (s KeyStats) Record(key string, correct bool, latency time.Duration) {
+	key = strings.ToLower(key)
+	stat, ok := s[key]
+	if !ok {
+		stat = &KeyStat{}
+		s[key] = stat
+	}
+
+	if !correct {
+		stat.Mistakes += 1
+		return
+	}
+
+	stat.Correct += 1
+	if latency > 0 {
+		stat.Timed += 1
+		stat.LatencyMs += float64(latency.Milliseconds())
+	}
+}
+
+// Keys returns the stats of single characters.
+func (s KeyStats) Keys() KeyStats {
+	return s.filter(1)
+}
+
+func (s KeyStats) Bigrams() KeyStats {
+	return s.filter(2)
+}
+
+func (s KeyStats) filter(length int) KeyStats {
+	filtered := KeyStats{}
+	for key, stat := range s {
+		if utf8.RuneCountInString(key) == length {
+			filtered[key] = stat
+		}
+	}
+	return filtered
+}
+
+func (s KeyStats) Merge(other KeyStats) {
+	for key, stat := range other {
+		if _, ok := s[key]; !ok {
+			s[key] = &KeyStat{}
+		}
+		s[key].Correct += stat.Correct
+		s[key].Mistakes += stat.Mistakes
+		s[key].Timed += stat.Timed
+		s[key].LatencyMs += stat.LatencyMs
+	}
+}
+
+func LoadKeyStats(store Store) (KeyStats, error) {
+	stats := KeyStats{}
+
+	path, err := store.path(KEYSTATS_FILE)
+	if err != nil {
+		return stats, err
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	} else if err != nil {
+		return stats, err
+	}
+
+	err = json.Unmarshal(content, &stats)
+	return stats, err
+}
+
+func (s KeyStats) Save(store Store) error {
+	path, err := store.path(KEYSTATS_FILE)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+// AppendKeyStats adds the key presses of a test to the saved totals.
+func AppendKeyStats(store Store, stats KeyStats) error {
+	total, err := LoadKeyStats(store)
+	if err != nil {
+		return err
+	}
+
+	total.Merge(stats)
+	return total.Save(store)
+}
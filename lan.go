@@ -0,0 +1,546 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// The race protocol is described in PROTOCOL.md, bump the version on any
+// change to it.
+const (
+	PROTOCOL_VERSION = 1
+	RACE_ADDR        = ":7878"
+	RACE_PLAYERS     = 2
+	RACE_WORDS       = 30
+	RACE_COUNTDOWN   = 3
+	RACE_TIMEOUT     = 5 * time.Second
+)
+
+const (
+	MSG_HELLO     = "hello"
+	MSG_WELCOME   = "welcome"
+	MSG_ERROR     = "error"
+	MSG_LOBBY     = "lobby"
+	MSG_RACE      = "race"
+	MSG_COUNTDOWN = "countdown"
+	MSG_START     = "start"
+	MSG_PROGRESS  = "progress"
+	MSG_FINISH    = "finish"
+	MSG_STATE     = "state"
+)
+
+// Message is a single line of the race protocol, only the fields of its
+// type are set.
+type Message struct {
+	Type     string  `json:"type"`
+	Version  int     `json:"version,omitempty"`
+	Name     string  `json:"name,omitempty"`
+	ID       int     `json:"id,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Needed   int     `json:"needed,omitempty"`
+	Text     string  `json:"text,omitempty"`
+	Config   *Config `json:"config,omitempty"`
+	Seconds  int     `json:"seconds,omitempty"`
+	Progress float64 `json:"progress,omitempty"`
+	Wpm      int     `json:"wpm,omitempty"`
+	Players  []Lane  `json:"players,omitempty"`
+}
+
+// serveCommand runs `monkeytype serve`, hosting races until it's killed.
+func serveCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("listen", RACE_ADDR, "address to accept players on")
+	players := flags.Int("players", RACE_PLAYERS, "players a race starts with")
+	wordCount := flags.Int("words", RACE_WORDS, "words in the race text")
+	punctuation := flags.Bool("punctuation", false, "add punctuation to the text")
+	_ = flags.Parse(args)
+
+	if available := len(strings.Fields(words)); *wordCount < 1 || *wordCount > available {
+		return fmt.Errorf("--words must be between 1 and %d", available)
+	}
+	if *players < 1 {
+		return errors.New("--players must be at least 1")
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("listening on %s, races start with %d players", l.Addr(), *players)
+
+	server := NewRaceServer(*players, Config{Words: *wordCount, Punctuation: *punctuation})
+	return server.Serve(l)
+}
+
+// joinCommand connects to the server given to `monkeytype join`.
+func joinCommand(args []string) (*NetRace, error) {
+	flags := flag.NewFlagSet("join", flag.ExitOnError)
+	name := flags.String("name", os.Getenv("USER"), "name shown to the other players")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return nil, errors.New("usage: monkeytype join [--name name] host:port")
+	}
+	return JoinRace(flags.Arg(0), *name)
+}
+
+type racePlayer struct {
+	lane Lane
+	conn net.Conn
+	enc  *json.Encoder
+}
+
+// RaceServer runs one race at a time: it waits for enough players, sends
+// them all the same text, counts down and relays their progress until
+// every one of them finished or left.
+type RaceServer struct {
+	needed int
+	config Config
+
+	mu      sync.Mutex
+	players []*racePlayer
+	nextID  int
+	started bool
+	places  int
+	// counts the races, so a countdown stops when its race ended early
+	round int
+}
+
+func NewRaceServer(needed int, config Config) *RaceServer {
+	return &RaceServer{
+		needed: needed,
+		config: config,
+	}
+}
+
+func (s *RaceServer) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *RaceServer) handle(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	var hello Message
+	if err := dec.Decode(&hello); err != nil || hello.Type != MSG_HELLO {
+		return
+	}
+	if hello.Version != PROTOCOL_VERSION {
+		msg := fmt.Sprintf("the server speaks version %d of the protocol, not %d", PROTOCOL_VERSION, hello.Version)
+		_ = enc.Encode(Message{Type: MSG_ERROR, Error: msg})
+		return
+	}
+
+	p, err := s.join(conn, enc, hello.Name)
+	if err != nil {
+		_ = enc.Encode(Message{Type: MSG_ERROR, Error: err.Error()})
+		return
+	}
+	defer s.leave(p)
+
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case MSG_PROGRESS:
+			s.progress(p, msg.Progress, msg.Wpm)
+		case MSG_FINISH:
+			s.finish(p, msg.Wpm)
+		}
+	}
+}
+
+func (s *RaceServer) join(conn net.Conn, enc *json.Encoder, name string) (*racePlayer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return nil, errors.New("a race is already running, try again once it's over")
+	}
+
+	s.nextID += 1
+	if name == "" {
+		name = fmt.Sprintf("player %d", s.nextID)
+	}
+	p := &racePlayer{lane: Lane{ID: s.nextID, Name: name}, conn: conn, enc: enc}
+	s.players = append(s.players, p)
+	s.send(p, Message{Type: MSG_WELCOME, Version: PROTOCOL_VERSION, ID: p.lane.ID})
+	log.Printf("%s joined (%d/%d)", name, len(s.players), s.needed)
+
+	s.broadcast(Message{Type: MSG_LOBBY, Needed: s.needed, Players: s.lanes()})
+	if len(s.players) >= s.needed {
+		s.started = true
+		go s.run(s.round)
+	}
+
+	return p, nil
+}
+
+// run sends the text and counts down to the start of the race.
+func (s *RaceServer) run(round int) {
+	config := s.config
+	config.Seed = time.Now().UnixNano()
+	text := generateWords(rand.New(rand.NewSource(config.Seed)), config)
+
+	s.mu.Lock()
+	if s.round != round {
+		s.mu.Unlock()
+		return
+	}
+	log.Printf("race started with seed %d", config.Seed)
+	s.broadcast(Message{Type: MSG_RACE, Text: text, Config: &config, Players: s.lanes()})
+	s.mu.Unlock()
+
+	for i := RACE_COUNTDOWN; i >= 0; i-- {
+		s.mu.Lock()
+		if s.round != round {
+			s.mu.Unlock()
+			return
+		}
+		if i > 0 {
+			s.broadcast(Message{Type: MSG_COUNTDOWN, Seconds: i})
+		} else {
+			s.broadcast(Message{Type: MSG_START})
+		}
+		s.mu.Unlock()
+
+		if i > 0 {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (s *RaceServer) progress(p *racePlayer, progress float64, wpm int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started || p.lane.Place > 0 {
+		return
+	}
+	p.lane.Progress = progress
+	p.lane.Wpm = wpm
+	s.broadcast(Message{Type: MSG_STATE, Players: s.lanes()})
+}
+
+func (s *RaceServer) finish(p *racePlayer, wpm int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started || p.lane.Place > 0 {
+		return
+	}
+	s.places += 1
+	p.lane.Place = s.places
+	p.lane.Progress = 1
+	p.lane.Wpm = wpm
+	s.broadcast(Message{Type: MSG_STATE, Players: s.lanes()})
+	s.checkOver()
+}
+
+func (s *RaceServer) leave(p *racePlayer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, other := range s.players {
+		if other != p {
+			continue
+		}
+		log.Printf("%s left", p.lane.Name)
+		if !s.started {
+			s.players = append(s.players[:i], s.players[i+1:]...)
+			s.broadcast(Message{Type: MSG_LOBBY, Needed: s.needed, Players: s.lanes()})
+			return
+		}
+		p.lane.Left = true
+		s.broadcast(Message{Type: MSG_STATE, Players: s.lanes()})
+		s.checkOver()
+		return
+	}
+}
+
+// checkOver ends the race once nobody is racing anymore, disconnecting the
+// players so a new one can start.
+func (s *RaceServer) checkOver() {
+	for _, p := range s.players {
+		if p.lane.Place == 0 && !p.lane.Left {
+			return
+		}
+	}
+
+	standings := s.lanes()
+	sortStandings(standings)
+	log.Printf("race over: %s", describeStandings(standings))
+
+	for _, p := range s.players {
+		p.conn.Close()
+	}
+	s.players = nil
+	s.started = false
+	s.places = 0
+	s.round += 1
+}
+
+func (s *RaceServer) lanes() []Lane {
+	lanes := make([]Lane, len(s.players))
+	for i, p := range s.players {
+		lanes[i] = p.lane
+	}
+	return lanes
+}
+
+// broadcast sends a message to every player, it must be called with the
+// lock held so messages keep their order.
+func (s *RaceServer) broadcast(msg Message) {
+	for _, p := range s.players {
+		s.send(p, msg)
+	}
+}
+
+func (s *RaceServer) send(p *racePlayer, msg Message) {
+	_ = p.conn.SetWriteDeadline(time.Now().Add(RACE_TIMEOUT))
+	if err := p.enc.Encode(msg); err != nil {
+		// the read loop notices the broken connection and removes the player
+		p.conn.Close()
+	}
+}
+
+// raceState is what a client knows about the race it joined.
+type raceState struct {
+	players   []Lane
+	needed    int
+	text      string
+	config    Config
+	countdown int
+	started   bool
+	closed    bool
+	err       error
+}
+
+var _ Race = (*NetRace)(nil)
+
+// NetRace is a client connection to a RaceServer.
+type NetRace struct {
+	conn   net.Conn
+	dec    *json.Decoder
+	id     int
+	screen tcell.Screen
+
+	mu    sync.Mutex
+	enc   *json.Encoder
+	view  Drawable
+	state raceState
+}
+
+// JoinRace connects to a server and introduces the player, failing when the
+// server can't take them.
+func JoinRace(addr, name string) (*NetRace, error) {
+	conn, err := net.DialTimeout("tcp", addr, RACE_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	r := &NetRace{
+		conn: conn,
+		dec:  json.NewDecoder(conn),
+		enc:  json.NewEncoder(conn),
+	}
+
+	if err := r.enc.Encode(Message{Type: MSG_HELLO, Version: PROTOCOL_VERSION, Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var welcome Message
+	if err := r.dec.Decode(&welcome); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if welcome.Type == MSG_ERROR {
+		conn.Close()
+		return nil, errors.New(welcome.Error)
+	}
+	if welcome.Type != MSG_WELCOME {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected %q message from the server", welcome.Type)
+	}
+	r.id = welcome.ID
+
+	return r, nil
+}
+
+// Listen reads the server's messages in the background, posting an
+// EventRace for each one.
+func (r *NetRace) Listen(screen tcell.Screen) {
+	r.screen = screen
+	go r.read()
+}
+
+func (r *NetRace) read() {
+	for {
+		var msg Message
+		err := r.dec.Decode(&msg)
+
+		r.mu.Lock()
+		switch {
+		case err != nil:
+			r.state.closed = true
+		case msg.Type == MSG_LOBBY:
+			r.state.players = msg.Players
+			r.state.needed = msg.Needed
+		case msg.Type == MSG_RACE:
+			r.state.players = msg.Players
+			r.state.text = msg.Text
+			if msg.Config != nil {
+				r.state.config = *msg.Config
+			}
+		case msg.Type == MSG_COUNTDOWN:
+			r.state.countdown = msg.Seconds
+		case msg.Type == MSG_START:
+			r.state.countdown = 0
+			r.state.started = true
+		case msg.Type == MSG_STATE:
+			r.state.players = msg.Players
+		case msg.Type == MSG_ERROR:
+			r.state.err = errors.New(msg.Error)
+		}
+		view := r.view
+		r.mu.Unlock()
+
+		if view != nil {
+			_ = r.screen.PostEvent(&EventRace{when: time.Now(), source: view})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *NetRace) State() raceState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+func (r *NetRace) Watch(view Drawable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.view = view
+}
+
+func (r *NetRace) send(msg Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.conn.SetWriteDeadline(time.Now().Add(RACE_TIMEOUT))
+	_ = r.enc.Encode(msg)
+}
+
+func (r *NetRace) Report(progress float64, wpm int) {
+	r.send(Message{Type: MSG_PROGRESS, Progress: progress, Wpm: wpm})
+}
+
+func (r *NetRace) Finish(wpm int) {
+	r.send(Message{Type: MSG_FINISH, Wpm: wpm})
+}
+
+func (r *NetRace) Leave() {
+	r.conn.Close()
+}
+
+func (r *NetRace) Lanes() []Lane {
+	var lanes []Lane
+	for _, lane := range r.State().players {
+		if lane.ID != r.id {
+			lanes = append(lanes, lane)
+		}
+	}
+	return lanes
+}
+
+func (r *NetRace) Standings() []Lane {
+	lanes := append([]Lane(nil), r.State().players...)
+	for i := range lanes {
+		if lanes[i].ID == r.id {
+			lanes[i].Name = "you"
+		}
+	}
+	sortStandings(lanes)
+	return lanes
+}
+
+var _ Drawable = (*Lobby)(nil)
+
+// Lobby waits with the other players for a LAN race to start.
+type Lobby struct {
+	screen tcell.Screen
+	race   *NetRace
+}
+
+func NewLobby(screen tcell.Screen, race *NetRace) Drawable {
+	return &Lobby{
+		screen: screen,
+		race:   race,
+	}
+}
+
+func (l *Lobby) Init() {
+	l.race.Watch(l)
+}
+
+func (l *Lobby) Draw() {
+	state := l.race.State()
+
+	title := "race lobby"
+	drawTextCentered(l.screen, len(title), 2, title, AppYellowTextStyle)
+
+	for i, p := range state.players {
+		name := p.Name
+		if p.ID == l.race.id {
+			name += " (you)"
+		}
+		drawTextCentered(l.screen, len(name), 4+i, name, AppTextStyle)
+	}
+
+	status := fmt.Sprintf("waiting for %d more players...", state.needed-len(state.players))
+	style := AppTextStyle
+	switch {
+	case state.err != nil:
+		status, style = state.err.Error(), WrongTextStyle
+	case state.closed:
+		status, style = "disconnected from the server, press enter to go back...", WrongTextStyle
+	case state.countdown > 0:
+		status, style = fmt.Sprintf("starting in %d...", state.countdown), AppYellowTextStyle
+	case state.text != "":
+		status = "get ready..."
+	}
+	drawTextCentered(l.screen, len(status), 6+len(state.players), status, style)
+}
+
+func (l *Lobby) Update(event tcell.Event) Drawable {
+	state := l.race.State()
+	if state.started && !state.closed {
+		return NewRaceTest(l.screen, l.race, state.text, state.config)
+	}
+
+	if k, ok := event.(*tcell.EventKey); ok && k.Key() == tcell.KeyEnter && state.closed {
+		return NewMenu(l.screen)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startRaceServer serves races of two players on a free local port.
+func startRaceServer(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go NewRaceServer(2, Config{Words: 10}).Serve(l)
+	return l.Addr().String()
+}
+
+func joinRace(t *testing.T, addr, name string) *NetRace {
+	t.Helper()
+
+	r, err := JoinRace(addr, name)
+	if err != nil {
+		t.Fatalf("%s couldn't join: %v", name, err)
+	}
+	t.Cleanup(r.Leave)
+	r.Listen(nil)
+	return r
+}
+
+// waitFor polls the race's state until done is satisfied.
+func waitFor(t *testing.T, r *NetRace, what string, done func(raceState) bool) raceState {
+	t.Helper()
+
+	deadline := time.Now().Add(RACE_COUNTDOWN*time.Second + RACE_TIMEOUT)
+	for time.Now().Before(deadline) {
+		if state := r.State(); done(state) {
+			return state
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+	return raceState{}
+}
+
+func TestRaceWelcome(t *testing.T) {
+	addr := startRaceServer(t)
+
+	alice := joinRace(t, addr, "alice")
+	bob := joinRace(t, addr, "bob")
+	if alice.id == 0 || bob.id == 0 || alice.id == bob.id {
+		t.Fatalf("got ids %d and %d, want distinct ones", alice.id, bob.id)
+	}
+}
+
+func TestRaceVersionMismatch(t *testing.T) {
+	addr := startRaceServer(t)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	hello := Message{Type: MSG_HELLO, Version: PROTOCOL_VERSION + 1, Name: "future"}
+	if err := json.NewEncoder(conn).Encode(hello); err != nil {
+		t.Fatal(err)
+	}
+	var reply Message
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Type != MSG_ERROR || !strings.Contains(reply.Error, "version") {
+		t.Fatalf("got %+v, want a version error", reply)
+	}
+}
+
+func TestRaceStartAndPlaces(t *testing.T) {
+	addr := startRaceServer(t)
+
+	alice := joinRace(t, addr, "alice")
+	bob := joinRace(t, addr, "bob")
+
+	started := func(s raceState) bool { return s.started }
+	aliceState := waitFor(t, alice, "alice's start", started)
+	bobState := waitFor(t, bob, "bob's start", started)
+	if aliceState.text == "" || aliceState.text != bobState.text {
+		t.Fatalf("got texts %q and %q, want the same one", aliceState.text, bobState.text)
+	}
+	if words := len(strings.Fields(aliceState.text)); words != 10 {
+		t.Fatalf("got %d words, want 10", words)
+	}
+
+	if _, err := JoinRace(addr, "carol"); err == nil {
+		t.Fatal("joined a running race")
+	}
+
+	bob.Finish(80)
+	waitFor(t, alice, "bob's finish", func(s raceState) bool {
+		return len(s.players) == 2 && (s.players[0].Place > 0 || s.players[1].Place > 0)
+	})
+	alice.Finish(60)
+
+	state := waitFor(t, alice, "the end of the race", func(s raceState) bool { return s.closed })
+	places := map[string]int{}
+	for _, p := range state.players {
+		places[p.Name] = p.Place
+	}
+	if places["bob"] != 1 || places["alice"] != 2 {
+		t.Fatalf("got places %v, want bob first and alice second", places)
+	}
+}
+
+func TestServeValidatesFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"--words", "0"},
+		{"--words", "-1"},
+		{"--words", "100000"},
+		{"--players", "0"},
+	} {
+		if err := serveCommand(args); err == nil {
+			t.Errorf("serve %v succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const VISIBLE_LINES = 3
+
+type styledRune struct {
+	ch    rune
+	style tcell.Style
+}
+
+// textLayout is the test text broken into word wrapped lines along with
+// the position of the caret inside of it.
+type textLayout struct {
+	lines     [][]styledRune
+	caretLine int
+	caretCol  int
+}
+
+func layoutText(target, typed string, lineLen int, blind, hideExtra bool) textLayout {
+	words, caretWord, caretOffset := styleWords(target, typed, blind, hideExtra)
+
+	layout := textLayout{}
+	var line []styledRune
+	for i, word := range words {
+		if len(line) > 0 && len(line)+1+len(word) > lineLen {
+			layout.lines = append(layout.lines, line)
+			line = nil
+		}
+
+		// words that don't fit on a line of their own are hard wrapped
+		for len(word) > lineLen && lineLen > 0 {
+			if i == caretWord && caretOffset >= lineLen {
+				caretOffset -= lineLen
+			} else if i == caretWord {
+				caretWord = -1
+				layout.caretLine, layout.caretCol = len(layout.lines), caretOffset
+			}
+			layout.lines = append(layout.lines, word[:lineLen])
+			word = word[lineLen:]
+		}
+
+		if len(line) > 0 {
+			line = append(line, styledRune{' ', AppTextStyle})
+		}
+		if i == caretWord {
+			layout.caretLine, layout.caretCol = len(layout.lines), len(line)+caretOffset
+		}
+		line = append(line, word...)
+	}
+	layout.lines = append(layout.lines, line)
+
+	// the caret after a full line goes to the start of the next one, where
+	// the next word is typed
+	if lineLen > 0 && layout.caretCol >= lineLen {
+		layout.caretLine, layout.caretCol = layout.caretLine+1, 0
+	}
+
+	return layout
+}
+
+// styleWords pairs every target word with what has been typed for it and
+// returns the styled words along with the word and offset of the caret.
+// Blind mode draws every typed character as correct and leaves out extras,
+// like hideExtra does.
+func styleWords(target, typed string, blind, hideExtra bool) ([][]styledRune, int, int) {
+	targetWords := strings.Split(target, " ")
+	typedWords := strings.Split(typed, " ")
+
+	words := make([][]styledRune, 0, len(targetWords))
+	for i, targetWord := range targetWords {
+		typedWord := ""
+		if i < len(typedWords) {
+			typedWord = typedWords[i]
+		}
+
+		var word []styledRune
+		for j := 0; j < len(targetWord); j++ {
+			style := TargetTextStyle
+			if j < len(typedWord) {
+				if targetWord[j] == typedWord[j] || blind {
+					style = CorrectTextStyle
+				} else {
+					style = WrongTextStyle
+				}
+			}
+			word = append(word, styledRune{rune(targetWord[j]), style})
+		}
+
+		if len(typedWord) > len(targetWord) && !hideExtra && !blind {
+			for _, ch := range typedWord[len(targetWord):] {
+				if string(ch) == WRONG_CHAR {
+					break
+				}
+				word = append(word, styledRune{ch, ExtraTextStyle})
+			}
+		}
+
+		words = append(words, word)
+	}
+
+	for i := len(targetWords); i < len(typedWords) && !blind; i++ {
+		var word []styledRune
+		for _, ch := range typedWords[i] {
+			word = append(word, styledRune{ch, WrongTextStyle})
+		}
+		words = append(words, word)
+	}
+
+	caretWord := len(typedWords) - 1
+	caretOffset := len(typedWords[caretWord])
+	if caretWord >= len(words) {
+		caretWord = len(words) - 1
+		caretOffset = len(words[caretWord])
+	}
+	if caretOffset > len(words[caretWord]) {
+		caretOffset = len(words[caretWord])
+	}
+
+	return words, caretWord, caretOffset
+}
+
+// layoutTape lays the whole text out on a single line, used when the
+// text scrolls horizontally under a fixed caret.
+func layoutTape(target, typed string, blind, hideExtra bool) textLayout {
+	words, caretWord, caretOffset := styleWords(target, typed, blind, hideExtra)
+
+	layout := textLayout{}
+	var line []styledRune
+	for i, word := range words {
+		if i > 0 {
+			line = append(line, styledRune{' ', AppTextStyle})
+		}
+		if i == caretWord {
+			layout.caretCol = len(line) + caretOffset
+		}
+		line = append(line, word...)
+	}
+	layout.lines = [][]styledRune{line}
+
+	return layout
+}
+
+// firstVisibleLine keeps the caret on the second visible line once the
+// first line has been typed, like the website does.
+func (l textLayout) firstVisibleLine() int {
+	if l.caretLine > 0 {
+		return l.caretLine - 1
+	}
+	return 0
+}
+
+// draw renders at most `visible` lines around the caret and returns the
+// screen position of the caret.
+func (l textLayout) draw(screen tcell.Screen, startW, startH, visible int) (int, int) {
+	first := l.firstVisibleLine()
+	for i := 0; i < visible && first+i < len(l.lines); i++ {
+		for j, r := range l.lines[first+i] {
+			screen.SetContent(startW+j, startH+i, r.ch, nil, r.style)
+		}
+	}
+
+	return startW + l.caretCol, startH + l.caretLine - first
+}
+
+// drawTape renders the single line of a tape layout so that the caret
+// stays in the middle of a window `width` cells wide, and returns the
+// screen position of the caret.
+func (l textLayout) drawTape(screen tcell.Screen, startW, startH, width int) (int, int) {
+	center := width / 2
+	offset := l.caretCol - center
+
+	line := l.lines[0]
+	for j := 0; j < width; j++ {
+		if offset+j < 0 || offset+j >= len(line) {
+			continue
+		}
+		r := line[offset+j]
+		screen.SetContent(startW+j, startH, r.ch, nil, r.style)
+	}
+
+	return startW + center, startH
+}
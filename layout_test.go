@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayoutText(t *testing.T) {
+	long := strings.Repeat("abcdefghij", 2)
+
+	for _, tt := range []struct {
+		name      string
+		target    string
+		typed     string
+		lineLen   int
+		blind     bool
+		hideExtra bool
+
+		lines     []string
+		caretLine int
+		caretCol  int
+	}{
+		{name: "nothing typed", target: "ab cd", lineLen: 10, lines: []string{"ab cd"}},
+		{name: "one line", target: "ab cd", typed: "ab c", lineLen: 10, lines: []string{"ab cd"}, caretCol: 4},
+		{name: "fits exactly", target: "abcd efgh", typed: "abcd e", lineLen: 9, lines: []string{"abcd efgh"}, caretCol: 6},
+		{name: "wraps at the space", target: "abcd efgh", typed: "abcd", lineLen: 8, lines: []string{"abcd", "efgh"}, caretCol: 4},
+		{name: "next line after the space", target: "abcd efgh", typed: "abcd ", lineLen: 8, lines: []string{"abcd", "efgh"}, caretLine: 1},
+		{name: "end of a full line", target: "abcd efgh ij", typed: "abcd efgh", lineLen: 9, lines: []string{"abcd efgh", "ij"}, caretLine: 1},
+		{name: "into a long word", target: long + " x", typed: long[:15], lineLen: 10, lines: []string{long[:10], long[10:], "x"}, caretLine: 1, caretCol: 5},
+		{name: "at a hard wrap", target: long + " x", typed: long[:10], lineLen: 10, lines: []string{long[:10], long[10:], "x"}, caretLine: 1},
+		{name: "after a long word", target: long + " x", typed: long, lineLen: 10, lines: []string{long[:10], long[10:], "x"}, caretLine: 2},
+		{name: "long word last", target: "x " + long, typed: "x " + long, lineLen: 10, lines: []string{"x", long[:10], long[10:]}, caretLine: 3},
+		{name: "longer than two lines", target: long + "abcde", typed: long + "ab", lineLen: 10, lines: []string{long[:10], long[10:], "abcde"}, caretLine: 2, caretCol: 2},
+		{name: "extra letters", target: "ab cd", typed: "abxy", lineLen: 10, lines: []string{"abxy cd"}, caretCol: 4},
+		{name: "extra letters wrap", target: "abcd ef", typed: "abcdxyz", lineLen: 8, lines: []string{"abcdxyz", "ef"}, caretCol: 7},
+		{name: "hidden extra letters", target: "ab cd", typed: "abxy", lineLen: 10, hideExtra: true, lines: []string{"ab cd"}, caretCol: 2},
+		{name: "blind", target: "ab cd", typed: "ax c", lineLen: 10, blind: true, lines: []string{"ab cd"}, caretCol: 4},
+		{name: "blind drops extras", target: "ab cd", typed: "abxy cdxy", lineLen: 10, blind: true, lines: []string{"ab cd"}, caretCol: 5},
+	} {
+		layout := layoutText(tt.target, tt.typed, tt.lineLen, tt.blind, tt.hideExtra)
+
+		var lines []string
+		for _, line := range layout.lines {
+			var b strings.Builder
+			for _, r := range line {
+				b.WriteRune(r.ch)
+			}
+			lines = append(lines, b.String())
+		}
+		if !reflect.DeepEqual(lines, tt.lines) {
+			t.Errorf("%s: got lines %q, want %q", tt.name, lines, tt.lines)
+		}
+		if layout.caretLine != tt.caretLine || layout.caretCol != tt.caretCol {
+			t.Errorf("%s: got the caret at %d:%d, want %d:%d",
+				tt.name, layout.caretLine, layout.caretCol, tt.caretLine, tt.caretCol)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// user layouts are json files in this directory of the config directory
+const LAYOUTS_DIR = "layouts"
+
+// Layout maps the keys of a QWERTY keyboard to another layout, row by row
+// and key by key, as they sit on QwertyRows. Shifted holds the same rows
+// typed with shift. It can only be left out when every key is a letter,
+// they're upper cased then.
+type Layout struct {
+	Name    string   `json:"name"`
+	Rows    []string `json:"rows"`
+	Shifted []string `json:"shifted,omitempty"`
+}
+
+var QwertyShifted = []string{"!@#$%^&*()_+", "QWERTYUIOP{}", "ASDFGHJKL:\"", "ZXCVBNM<>?"}
+
+var BundledLayouts = []Layout{
+	{"qwerty", QwertyRows, QwertyShifted},
+	{
+		"dvorak",
+		[]string{"1234567890[]", "',.pyfgcrl/=", "aoeuidhtns-", ";qjkxbmwvz"},
+		[]string{"!@#$%^&*(){}", "\"<>PYFGCRL?+", "AOEUIDHTNS_", ":QJKXBMWVZ"},
+	},
+	{
+		"colemak",
+		[]string{"1234567890-=", "qwfpgjluy;[]", "arstdhneio'", "zxcvbkm,./"},
+		[]string{"!@#$%^&*()_+", "QWFPGJLUY:{}", "ARSTDHNEIO\"", "ZXCVBKM<>?"},
+	},
+	{
+		"workman",
+		[]string{"1234567890-=", "qdrwbjfup;[]", "ashtgyneoi'", "zxmcvkl,./"},
+		[]string{"!@#$%^&*()_+", "QDRWBJFUP:{}", "ASHTGYNEOI\"", "ZXMCVKL<>?"},
+	},
+}
+
+var (
+	Layouts     = append(BundledLayouts, loadUserLayouts()...)
+	LayoutNames = layoutNames(Layouts)
+)
+
+func layoutNames(layouts []Layout) []string {
+	names := make([]string, len(layouts))
+	for i, l := range layouts {
+		names[i] = l.Name
+	}
+	return names
+}
+
+// loadUserLayouts reads the layouts the user saved in the layouts directory,
+// skipping the files that can't be read or don't match the QWERTY rows.
+func loadUserLayouts() []Layout {
+	dir, err := LocalStore.path(LAYOUTS_DIR)
+	if err != nil {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(paths)
+
+	var layouts []Layout
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var l Layout
+		if err := json.Unmarshal(content, &l); err != nil || !l.valid() {
+			continue
+		}
+		if l.Name == "" {
+			l.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		layouts = append(layouts, l)
+	}
+
+	return layouts
+}
+
+// valid reports whether every row has as many keys as its QWERTY row, and
+// whether shift can be worked out for every key.
+func (l Layout) valid() bool {
+	if !sameShape(l.Rows, QwertyRows) {
+		return false
+	}
+	if l.Shifted == nil {
+		return onlyLetters(l.Rows)
+	}
+	return sameShape(l.Shifted, QwertyShifted)
+}
+
+func onlyLetters(rows []string) bool {
+	for _, row := range rows {
+		for _, r := range row {
+			if !unicode.IsLetter(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sameShape(rows, qwerty []string) bool {
+	if len(rows) != len(qwerty) {
+		return false
+	}
+	for i := range rows {
+		if utf8.RuneCountInString(rows[i]) != utf8.RuneCountInString(qwerty[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Translate returns the character the layout puts on the key that types r
+// on QWERTY. Characters that aren't on the keyboard are left untouched.
+func (l Layout) Translate(r rune) rune {
+	if row, col, ok := keyPosition(QwertyRows, r); ok {
+		return []rune(l.Rows[row])[col]
+	}
+	if row, col, ok := keyPosition(QwertyShifted, r); ok {
+		if l.Shifted != nil {
+			return []rune(l.Shifted[row])[col]
+		}
+		return unicode.ToUpper([]rune(l.Rows[row])[col])
+	}
+	return r
+}
+
+func keyPosition(rows []string, r rune) (int, int, bool) {
+	for i, row := range rows {
+		if col := strings.IndexRune(row, r); col >= 0 {
+			return i, utf8.RuneCountInString(row[:col]), true
+		}
+	}
+	return 0, 0, false
+}
+
+// currentLayout returns the layout picked in the settings, or QWERTY when
+// it's a user layout that has been removed since.
+func currentLayout(p *Preferences) Layout {
+	for _, l := range Layouts {
+		if l.Name == p.Layout {
+			return l
+		}
+	}
+	return Layouts[0]
+}
+
+// translateKey emulates the current layout on a QWERTY keyboard.
+func translateKey(key *tcell.EventKey, p *Preferences) *tcell.EventKey {
+	if key.Key() != tcell.KeyRune {
+		return key
+	}
+	layout := currentLayout(p)
+	if layout.Name == Layouts[0].Name {
+		return key
+	}
+	return tcell.NewEventKey(tcell.KeyRune, layout.Translate(key.Rune()), key.Modifiers())
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBundledLayouts(t *testing.T) {
+	qwerty := strings.Join(QwertyRows, "") + strings.Join(QwertyShifted, "")
+
+	for _, l := range BundledLayouts {
+		if !l.valid() {
+			t.Errorf("%s isn't valid", l.Name)
+			continue
+		}
+
+		// every key types a character of its own
+		seen := map[rune]rune{}
+		for _, r := range qwerty {
+			got := l.Translate(r)
+			if prev, ok := seen[got]; ok {
+				t.Errorf("%s types %q on both %q and %q", l.Name, got, prev, r)
+			}
+			seen[got] = r
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	layouts := map[string]Layout{}
+	for _, l := range BundledLayouts {
+		layouts[l.Name] = l
+	}
+	letters := Layout{
+		Name: "letters",
+		Rows: []string{"abcdefghijkl", "mnopqrstuvwx", "yzabcdefghi", "jklmnopqrs"},
+	}
+	layouts[letters.Name] = letters
+
+	for _, tt := range []struct {
+		layout string
+		in     rune
+		want   rune
+	}{
+		{"qwerty", 'q', 'q'},
+		{"qwerty", '"', '"'},
+		{"dvorak", 's', 'o'},
+		{"dvorak", 'q', '\''},
+		{"dvorak", '\'', '-'},
+		{"dvorak", '"', '_'},
+		{"dvorak", 'Z', ':'},
+		{"colemak", 'e', 'f'},
+		{"colemak", ';', 'o'},
+		{"colemak", ':', 'O'},
+		{"workman", 'd', 'h'},
+		{"workman", 'P', ':'},
+		// keys that aren't on the keyboard
+		{"dvorak", ' ', ' '},
+		{"dvorak", 'é', 'é'},
+		// letters only layouts upper case on shift
+		{"letters", 'q', 'm'},
+		{"letters", 'Q', 'M'},
+		{"letters", '!', 'A'},
+	} {
+		if got := layouts[tt.layout].Translate(tt.in); got != tt.want {
+			t.Errorf("%s: got %q for %q, want %q", tt.layout, got, tt.in, tt.want)
+		}
+	}
+}
+
+func TestLayoutValid(t *testing.T) {
+	dvorak := BundledLayouts[1]
+	for _, tt := range []struct {
+		name   string
+		layout Layout
+		want   bool
+	}{
+		{"bundled", dvorak, true},
+		{"symbols without shifted", Layout{Rows: dvorak.Rows}, false},
+		{"short row", Layout{Rows: []string{"1234567890-", "',.pyfgcrl/=", "aoeuidhtns-", ";qjkxbmwvz"}, Shifted: dvorak.Shifted}, false},
+		{"missing row", Layout{Rows: dvorak.Rows[:3], Shifted: dvorak.Shifted[:3]}, false},
+		{"short shifted row", Layout{Rows: dvorak.Rows, Shifted: []string{"!@#$%^&*(){}", "\"<>PYFGCRL?+", "AOEUIDHTNS_", ":QJKXBMWV"}}, false},
+	} {
+		if got := tt.layout.valid(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const LEADERBOARD_FILE = "leaderboard.jsonl"
+
+const (
+	RANGE_TODAY int = iota
+	RANGE_WEEK
+	RANGE_MONTH
+	RANGE_ALL
+)
+
+var (
+	// only tests without punctuation or numbers are ranked, so scores compare
+	LeaderboardModes  = append([]string{"time 15", "time 60", "words 25", "words 50"}, quoteModes()...)
+	LeaderboardRanges = []string{"today", "last 7 days", "last 30 days", "all time"}
+)
+
+func quoteModes() []string {
+	modes := make([]string, len(QuoteTypes))
+	for i, q := range QuoteTypes {
+		modes[i] = "quote " + q
+	}
+	return modes
+}
+
+// LeaderboardEntry is a score as appended to the leaderboard file, one JSON
+// object per line. The file can be shared by a whole team.
+type LeaderboardEntry struct {
+	Date time.Time `json:"date"`
+	User string    `json:"user"`
+	// the SSH key fingerprint of remote users, telling apart those who
+	// picked the same name
+	Key         string `json:"key,omitempty"`
+	Mode        string `json:"mode"`
+	Wpm         int    `json:"wpm"`
+	RawWpm      int    `json:"raw_wpm"`
+	Accuracy    int    `json:"accuracy"`
+	Consistency int    `json:"consistency"`
+}
+
+// testMode names the mode of a time, words or quote test, like "time 15"
+// or "words 50 punctuation". Other tests have none, and neither do given
+// texts and fixed seeds, like imported tests and the daily challenge, as
+// they aren't random.
+func testMode(kind int, conf Config, givenText bool) string {
+	if givenText || conf.Seed != 0 {
+		return ""
+	}
+
+	var mode string
+	switch kind {
+	case TEST_TIME:
+		mode = fmt.Sprintf("time %d", conf.Duration)
+	case TEST_WORD:
+		mode = fmt.Sprintf("words %d", conf.Words)
+	case TEST_QUOTE:
+		mode = "quote " + QuoteTypes[conf.QuoteLen]
+	default:
+		return ""
+	}
+
+	if conf.Punctuation {
+		mode += " punctuation"
+	}
+	if conf.Number {
+		mode += " numbers"
+	}
+	return mode
+}
+
+// leaderboardMode returns the mode a test is ranked in, if any.
+func leaderboardMode(kind int, conf Config, givenText bool) (string, bool) {
+	mode := testMode(kind, conf, givenText)
+	for _, m := range LeaderboardModes {
+		if m == mode {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+// leaderboardPath returns the leaderboard file set in the settings, which
+// can also be a directory to keep it in. It's in the config directory by
+// default.
+func leaderboardPath() (string, error) {
+	if Prefs.Leaderboard == "" {
+		return LocalStore.path(LEADERBOARD_FILE)
+	}
+
+	if info, err := os.Stat(Prefs.Leaderboard); err == nil && info.IsDir() {
+		return filepath.Join(Prefs.Leaderboard, LEADERBOARD_FILE), nil
+	}
+	return Prefs.Leaderboard, nil
+}
+
+// AppendLeaderboard adds a score to the leaderboard, holding a lock on the
+// file so players writing at once don't mix their lines.
+func AppendLeaderboard(entry LeaderboardEntry) error {
+	path, err := leaderboardPath()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFile(f, true); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	_, err = f.Write(append(content, '\n'))
+	return err
+}
+
+// LoadLeaderboard returns every score on the leaderboard. Lines that can't be
+// parsed are skipped.
+func LoadLeaderboard() ([]LeaderboardEntry, error) {
+	path, err := leaderboardPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := lockFile(f, false); err != nil {
+		return nil, err
+	}
+	defer unlockFile(f)
+
+	var entries []LeaderboardEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry LeaderboardEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
+
+// topScores returns the best score of every user in a mode since a date,
+// fastest first. Ties go to the more accurate and then the earlier score.
+func topScores(entries []LeaderboardEntry, mode string, since time.Time) []LeaderboardEntry {
+	var scores []LeaderboardEntry
+	for _, e := range entries {
+		if e.Mode == mode && !e.Date.Before(since) {
+			scores = append(scores, e)
+		}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Wpm != scores[j].Wpm {
+			return scores[i].Wpm > scores[j].Wpm
+		}
+		if scores[i].Accuracy != scores[j].Accuracy {
+			return scores[i].Accuracy > scores[j].Accuracy
+		}
+		return scores[i].Date.Before(scores[j].Date)
+	})
+
+	seen := map[string]bool{}
+	best := scores[:0]
+	for _, s := range scores {
+		if !seen[s.player()] {
+			seen[s.player()] = true
+			best = append(best, s)
+		}
+	}
+	return best
+}
+
+// player names who set the score, with the start of their key's fingerprint
+// for remote users.
+func (e LeaderboardEntry) player() string {
+	fingerprint := strings.TrimPrefix(e.Key, "SHA256:")
+	if len(fingerprint) > 6 {
+		fingerprint = fingerprint[:6]
+	}
+	if fingerprint == "" {
+		return e.User
+	}
+	return e.User + "@" + fingerprint
+}
+
+// rangeStart returns when a date range of the leaderboard starts.
+func rangeStart(r int, now time.Time) time.Time {
+	switch r {
+	case RANGE_TODAY:
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	case RANGE_WEEK:
+		return now.AddDate(0, 0, -7)
+	case RANGE_MONTH:
+		return now.AddDate(0, 0, -30)
+	}
+	return time.Time{}
+}
+
+// usernameOf returns the name scores are saved under: the name bound to the
+// key of remote users, otherwise the one in the settings or the system's.
+func usernameOf(screen tcell.Screen) string {
+	if s := sessionOf(screen); s.user != "" {
+		return s.user
+	}
+	if Prefs.Username != "" {
+		return Prefs.Username
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "anonymous"
+}
+
+var _ Drawable = (*Leaderboard)(nil)
+
+type Leaderboard struct {
+	screen    tcell.Screen
+	entries   []LeaderboardEntry
+	err       error
+	mode      int
+	dateRange int
+}
+
+func NewLeaderboard(screen tcell.Screen) Drawable {
+	return &Leaderboard{
+		screen:    screen,
+		dateRange: RANGE_ALL,
+	}
+}
+
+func (l *Leaderboard) Init() {
+	l.entries, l.err = LoadLeaderboard()
+}
+
+func (l *Leaderboard) Draw() {
+	title := fmt.Sprintf("leaderboard: %s, %s", LeaderboardModes[l.mode], LeaderboardRanges[l.dateRange])
+	drawTextCentered(l.screen, len(title), 2, title, AppYellowTextStyle)
+
+	_, sHeight := l.screen.Size()
+	help := "left/right to change the mode, up/down the dates, r to reload, enter to go back..."
+	drawTextCentered(l.screen, len(help), sHeight-2, help, AppTextStyle)
+
+	if l.err != nil {
+		msg := l.err.Error()
+		drawTextCentered(l.screen, len(msg), 5, msg, WrongTextStyle)
+		return
+	}
+
+	scores := topScores(l.entries, LeaderboardModes[l.mode], rangeStart(l.dateRange, time.Now()))
+	if len(scores) == 0 {
+		msg := "no scores yet, finish a test in this mode first..."
+		drawTextCentered(l.screen, len(msg), 5, msg, AppTextStyle)
+		return
+	}
+
+	header := fmt.Sprintf("%4s  %-23s %5s %5s %5s %5s  %-10s", "#", "user", "wpm", "raw", "acc", "cons", "date")
+	startW := centerWidth(l.screen, len(header))
+	drawText(l.screen, len(header), startW, 4, header, TargetTextStyle)
+
+	me := LeaderboardEntry{User: usernameOf(l.screen), Key: sessionOf(l.screen).key}.player()
+	for i, s := range scores {
+		row := 6 + i
+		if row >= sHeight-3 {
+			break
+		}
+		// long names are cut, not the fingerprint after them
+		name := s.player()
+		if len(name) > 23 {
+			fingerprint := name[len(s.User):]
+			name = s.User[:23-len(fingerprint)] + fingerprint
+		}
+		line := fmt.Sprintf("%4d  %-23s %5d %5d %4d%% %4d%%  %s",
+			i+1, name, s.Wpm, s.RawWpm, s.Accuracy, s.Consistency, s.Date.Local().Format("2006-01-02"))
+		style := AppTextStyle
+		if s.player() == me {
+			style = AppYellowTextStyle
+		}
+		drawText(l.screen, len(line), startW, row, line, style)
+	}
+}
+
+func (l *Leaderboard) Update(event tcell.Event) Drawable {
+	k := event.(*tcell.EventKey)
+	switch k.Key() {
+	case tcell.KeyLeft:
+		l.mode = (l.mode - 1 + len(LeaderboardModes)) % len(LeaderboardModes)
+	case tcell.KeyRight:
+		l.mode = (l.mode + 1) % len(LeaderboardModes)
+	case tcell.KeyUp:
+		l.dateRange = (l.dateRange - 1 + len(LeaderboardRanges)) % len(LeaderboardRanges)
+	case tcell.KeyDown:
+		l.dateRange = (l.dateRange + 1) % len(LeaderboardRanges)
+	case tcell.KeyEnter:
+		return NewMenu(l.screen)
+	case tcell.KeyRune:
+		if k.Rune() == 'r' {
+			l.Init()
+		}
+	}
+
+	return nil
+}
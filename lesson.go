@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+const (
+	LESSON_FILE = "lesson.json"
+	// letters are unlocked in this order, starting from the home row
+	UNLOCK_ORDER    = "asdfjklehirunotgcmpywbvxqz"
+	INITIAL_LETTERS = 7
+	// below this many real words the text is padded with pseudo words
+	MIN_LESSON_WORDS = 20
+	// how much a single test moves a letter's smoothed speed and accuracy
+	LESSON_SMOOTHING = 0.5
+)
+
+const VOWELS = "aeiouy"
+
+// LetterProgress is the smoothed speed and accuracy of a single letter
+// across lesson tests.
+type LetterProgress struct {
+	Wpm      float64 `json:"wpm"`
+	Accuracy float64 `json:"accuracy"`
+	Tests    int     `json:"tests"`
+}
+
+// LessonProgress is how far the user got in the letter unlocking lesson.
+type LessonProgress struct {
+	Unlocked int                        `json:"unlocked"`
+	Stats    map[string]*LetterProgress `json:"letters"`
+}
+
+// LoadLessonProgress reads the saved progress, bringing a hand edited file
+// back to something the lesson can work with.
+func LoadLessonProgress(store Store) LessonProgress {
+	progress := LessonProgress{
+		Unlocked: INITIAL_LETTERS,
+		Stats:    map[string]*LetterProgress{},
+	}
+
+	path, err := store.path(LESSON_FILE)
+	if err != nil {
+		return progress
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return progress
+	}
+	_ = json.Unmarshal(content, &progress)
+
+	progress.Unlocked = min(max(progress.Unlocked, 1), len(UNLOCK_ORDER))
+	if progress.Stats == nil {
+		progress.Stats = map[string]*LetterProgress{}
+	}
+	for letter, p := range progress.Stats {
+		if p == nil {
+			delete(progress.Stats, letter)
+		}
+	}
+
+	return progress
+}
+
+func (l LessonProgress) Save(store Store) error {
+	path, err := store.path(LESSON_FILE)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+func (l LessonProgress) Letters() string {
+	return UNLOCK_ORDER[:l.Unlocked]
+}
+
+// Passed reports whether a letter reached the target speed and accuracy.
+func (l LessonProgress) Passed(letter string, prefs *Preferences) bool {
+	p, ok := l.Stats[letter]
+	return ok && p.Tests > 0 && p.Wpm >= float64(prefs.LessonWpm) && p.Accuracy >= float64(prefs.LessonAccuracy)
+}
+
+// Focus returns the unlocked letter furthest from the target speed, which
+// lesson texts favor.
+func (l LessonProgress) Focus() string {
+	focus := string(l.Letters()[l.Unlocked-1])
+	lowest := -1.0
+	for _, letter := range l.Letters() {
+		p, ok := l.Stats[string(letter)]
+		if !ok || p.Tests == 0 {
+			return string(letter)
+		}
+		if lowest < 0 || p.Wpm < lowest {
+			focus, lowest = string(letter), p.Wpm
+		}
+	}
+	return focus
+}
+
+// Record folds the key stats of a lesson test into the letters' progress
+// and unlocks the next letter once every current one passes. It returns
+// the newly unlocked letter, if any.
+func (l *LessonProgress) Record(store Store, stats KeyStats, prefs *Preferences) (string, error) {
+	for _, letter := range l.Letters() {
+		stat, ok := stats[string(letter)]
+		if !ok || stat.Presses() == 0 || stat.Timed == 0 {
+			continue
+		}
+
+		// a key pressed every n ms is typed at 60000/n characters a minute
+		wpm := 60000 / stat.AvgLatency() / 5
+		accuracy := float64(stat.Correct) / float64(stat.Presses()) * 100
+
+		p, ok := l.Stats[string(letter)]
+		if !ok {
+			p = &LetterProgress{Wpm: wpm, Accuracy: accuracy}
+			l.Stats[string(letter)] = p
+		}
+		p.Wpm += (wpm - p.Wpm) * LESSON_SMOOTHING
+		p.Accuracy += (accuracy - p.Accuracy) * LESSON_SMOOTHING
+		p.Tests += 1
+	}
+
+	unlocked := ""
+	if l.Unlocked < len(UNLOCK_ORDER) && l.allPassed(prefs) {
+		unlocked = string(UNLOCK_ORDER[l.Unlocked])
+		l.Unlocked += 1
+	}
+
+	return unlocked, l.Save(store)
+}
+
+func (l LessonProgress) allPassed(prefs *Preferences) bool {
+	for _, letter := range l.Letters() {
+		if !l.Passed(string(letter), prefs) {
+			return false
+		}
+	}
+	return true
+}
+
+// generateLessonWords builds a text out of the unlocked letters only, half
+// of its words containing the focused letter.
+func generateLessonWords(r *rand.Rand, conf Config, progress LessonProgress) string {
+	letters := progress.Letters()
+	focus := progress.Focus()
+	pool := filterWords(strings.Fields(words), letters)
+
+	var focused []string
+	for _, w := range pool {
+		if strings.Contains(w, focus) {
+			focused = append(focused, w)
+		}
+	}
+
+	selectedWords := make([]string, conf.Words)
+	for i := range selectedWords {
+		switch {
+		case i%2 == 0 && len(focused) > 0:
+			selectedWords[i] = focused[r.Intn(len(focused))]
+		case len(pool) >= MIN_LESSON_WORDS || (len(pool) > 0 && r.Intn(2) == 0):
+			selectedWords[i] = pool[r.Intn(len(pool))]
+		default:
+			selectedWords[i] = pseudoWord(r, letters, focus)
+		}
+	}
+
+	return strings.Join(selectedWords, " ")
+}
+
+// filterWords keeps the words made only of the given letters.
+func filterWords(words []string, letters string) []string {
+	var filtered []string
+	for _, w := range words {
+		if strings.Trim(w, letters) == "" {
+			filtered = append(filtered, w)
+		}
+	}
+	return filtered
+}
+
+// pseudoWord makes up a pronounceable looking word from the given letters,
+// alternating vowels and consonants when both are available. When must
+// isn't empty the word contains it.
+func pseudoWord(r *rand.Rand, letters, must string) string {
+	var vowels, consonants []byte
+	for i := 0; i < len(letters); i++ {
+		if strings.IndexByte(VOWELS, letters[i]) >= 0 {
+			vowels = append(vowels, letters[i])
+		} else {
+			consonants = append(consonants, letters[i])
+		}
+	}
+
+	length := 3 + r.Intn(4)
+	word := make([]byte, 0, length)
+	vowel := r.Intn(2) == 0
+	for len(word) < length {
+		group := consonants
+		if (vowel && len(vowels) > 0) || len(consonants) == 0 {
+			group = vowels
+		}
+		word = append(word, group[r.Intn(len(group))])
+		vowel = !vowel
+	}
+
+	if must != "" && !strings.Contains(string(word), must) {
+		i := r.Intn(len(word) - len(must) + 1)
+		copy(word[i:], must)
+	}
+
+	return string(word)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	"github.com/gdamore/tcell/v2"
@@ -14,6 +15,7 @@ var (
 	CorrectTextStyle   = tcell.StyleDefault.Background(BackgroundColor).Foreground(tcell.Color252)
 	WrongTextStyle     = tcell.StyleDefault.Background(BackgroundColor).Foreground(tcell.Color197)
 	ExtraTextStyle     = tcell.StyleDefault.Background(BackgroundColor).Foreground(tcell.Color196)
+	CaretTextStyle     = tcell.StyleDefault.Background(BackgroundColor).Foreground(tcell.Color214)
 )
 
 func FillBackground(s tcell.Screen, color tcell.Color) {
@@ -27,6 +29,34 @@ func FillBackground(s tcell.Screen, color tcell.Color) {
 }
 
 func main() {
+	flag.Int64Var(&FixedSeed, "seed", 0, "generate the text of every test from this seed")
+	flag.Parse()
+
+	var race *NetRace
+	switch flag.Arg(0) {
+	case "serve":
+		if err := serveCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "api":
+		if err := apiCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "serve-ssh":
+		if err := serveSSHCommand(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "join":
+		r, err := joinCommand(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		race = r
+	}
+
 	// Initialize screen
 	s, err := tcell.NewScreen()
 	if err != nil {
@@ -35,7 +65,6 @@ func main() {
 	if err := s.Init(); err != nil {
 		log.Fatalf("%+v", err)
 	}
-	s.SetCursorStyle(tcell.CursorStyleBlinkingUnderline)
 	s.Clear()
 
 	quit := func() {
@@ -47,12 +76,30 @@ func main() {
 	}
 	defer quit()
 
-	currElement := NewMenu(s)
+	session := NewSession(s, LocalStore, false)
+	currElement := NewMenu(session)
+	if race != nil {
+		race.Listen(session)
+		currElement = NewLobby(session, race)
+	}
+	run(session, currElement)
+}
+
+// run draws the screen and dispatches its events until the user quits or
+// the screen breaks.
+func run(s tcell.Screen, currElement Drawable) {
 	currElement.Init()
+	defer func() {
+		// a running test would otherwise keep ticking
+		if t, ok := currElement.(*Test); ok {
+			t.stopTicker()
+		}
+	}()
 
 	// Event loop
 	for {
 		s.Clear()
+		s.HideCursor()
 		FillBackground(s, BackgroundColor)
 		currElement.Draw()
 		s.Show()
@@ -61,17 +108,41 @@ func main() {
 		ev := s.PollEvent()
 
 		switch ev := ev.(type) {
+		case nil, *tcell.EventError:
+			return
 		case *tcell.EventResize:
 			s.Clear()
 			currElement.Draw()
 			s.Sync()
 		case *tcell.EventKey:
-			if ev.Key() == tcell.KeyEscape || (ev.Key() == tcell.KeyRune && ev.Rune() == 'q') {
+			input, ok := currElement.(TextInput)
+			typing := ok && input.TakesText()
+			if ev.Key() == tcell.KeyEscape || (ev.Key() == tcell.KeyRune && ev.Rune() == 'q' && !typing) {
 				return
 			} else if ev.Key() == tcell.KeyCtrlL {
 				s.Sync()
 			}
 
+			nextElement := currElement.Update(ev)
+			if nextElement != nil {
+				currElement = nextElement
+				currElement.Init()
+			}
+		case *EventTick:
+			if ev.source != currElement {
+				continue
+			}
+
+			nextElement := currElement.Update(ev)
+			if nextElement != nil {
+				currElement = nextElement
+				currElement.Init()
+			}
+		case *EventRace:
+			if ev.source != currElement {
+				continue
+			}
+
 			nextElement := currElement.Update(ev)
 			if nextElement != nil {
 				currElement = nextElement
@@ -3,33 +3,51 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
 
 type TestPrompt interface {
 	Name() string
+	// Kind is the kind of the tests the prompt starts
+	Kind() int
 	Draw(screen tcell.Screen, startRow, startCol, boxWidth int)
 	Update(e *tcell.EventKey) bool
 	Config() Config
 }
 
-var TestTypes = []TestPrompt{
-	&WordPrompt{wordCount: 2, inCheckList: true},
-	&TimePrompt{duration: 2, inCheckList: true},
-	&QuotePrompt{},
+// promptLoader is a prompt that shows saved state, read once whenever the
+// menu is shown rather than on every redraw.
+type promptLoader interface {
+	Load(store Store)
+}
+
+// NewTestTypes returns the prompts of the test kinds the menu lists, in the
+// order they're shown.
+func NewTestTypes() []TestPrompt {
+	return []TestPrompt{
+		NewWordPrompt(),
+		NewTimePrompt(),
+		&QuotePrompt{},
+		NewPracticePrompt(),
+		&LessonPrompt{},
+		NewDrillPrompt(),
+		NewRacePrompt(),
+	}
 }
 
 const (
 	PUNCTUATION int = iota
 	NUMBER
+	BLIND
 )
 
 var (
 	BoxStyle      = tcell.StyleDefault.Background(tcell.Color237).Foreground(tcell.Color252)
 	SelectedStyle = tcell.StyleDefault.Background(tcell.Color237).Foreground(tcell.Color214)
 
-	CheckListItems   = []string{"punctuation", "numbers"}
+	CheckListItems   = []string{"punctuation", "numbers", "blind"}
 	DurationChoices  = []string{"15", "30", "60", "120"}
 	WordCountChoices = []string{"10", "25", "50", "100"}
 )
@@ -37,23 +55,33 @@ var (
 var _ Drawable = (*Menu)(nil)
 
 type Menu struct {
-	screen   tcell.Screen
+	screen    tcell.Screen
+	testTypes []TestPrompt
+	// the row of the selected test type
 	testType int
 	config   Config
 
 	inPrompt bool
+
+	// the box a shared test code is pasted in
+	importing bool
+	code      string
+	importErr error
 }
 
 func NewMenu(screen tcell.Screen) Drawable {
 	return &Menu{
-		screen,
-		TEST_WORD,
-		Config{},
-		false,
+		screen:    screen,
+		testTypes: sessionOf(screen).testTypes,
 	}
 }
 
 func (m *Menu) Init() {
+	for _, p := range m.testTypes {
+		if l, ok := p.(promptLoader); ok {
+			l.Load(storeOf(m.screen))
+		}
+	}
 }
 
 func (m *Menu) Draw() {
@@ -61,18 +89,63 @@ func (m *Menu) Draw() {
 	text := "choose a style, press s to start test..."
 
 	startingRow = drawTextCentered(m.screen, len(text), startingRow, text, AppTextStyle)
-	m.drawChoiceBox(m.screen, startingRow)
+	if m.importing {
+		m.drawImportBox(startingRow)
+	} else {
+		m.drawChoiceBox(m.screen, startingRow)
+	}
+
+	// the help is wrapped so narrow terminals don't cut it off
+	sWidth, sHeight := m.screen.Size()
+	help := "press o to open settings, d for the daily challenge, i to import a test code, l for lessons, p to practice your missed words, k for the key heatmap, b for the leaderboard..."
+	lines := splitTextIntoLines(help, sWidth-4)
+	for i, line := range lines {
+		drawTextCentered(m.screen, len(line), sHeight-1-len(lines)+i, line, AppTextStyle)
+	}
+}
+
+func (m *Menu) TakesText() bool {
+	return m.importing
 }
 
 func (m *Menu) Update(event tcell.Event) Drawable {
 	k := event.(*tcell.EventKey)
+	if m.importing {
+		return m.updateImport(k)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'i' {
+		m.importing = true
+		return nil
+	}
 	if k.Key() == tcell.KeyRune && k.Rune() == 's' {
-		conf := TestTypes[m.testType].Config()
-		return NewTest(m.screen, m.testType, conf)
+		conf := m.testTypes[m.testType].Config()
+		conf.Seed = FixedSeed
+		return NewTest(m.screen, m.testTypes[m.testType].Kind(), conf)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'd' {
+		return NewTest(m.screen, TEST_WORD, dailyChallenge(time.Now()))
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'o' {
+		return NewSettings(m.screen)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'l' {
+		return NewLessons(m.screen)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'k' {
+		return NewHeatmap(m.screen)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'b' {
+		return NewLeaderboard(m.screen)
+	}
+	if k.Key() == tcell.KeyRune && k.Rune() == 'p' {
+		if words := historyPracticeWords(storeOf(m.screen), prefsOf(m.screen).PracticeTests); len(words) > 0 {
+			return NewPracticeTest(m.screen, words)
+		}
+		return nil
 	}
 
 	if m.inPrompt {
-		if TestTypes[m.testType].Update(k) {
+		if m.testTypes[m.testType].Update(k) {
 			m.inPrompt = false
 		}
 	} else {
@@ -81,25 +154,68 @@ func (m *Menu) Update(event tcell.Event) Drawable {
 			if m.testType > 0 {
 				m.testType -= 1
 			} else {
-				m.testType = len(TestTypes) - 1
+				m.testType = len(m.testTypes) - 1
 			}
 		case tcell.KeyRight:
-			if m.testType < len(TestTypes)-1 {
+			if m.testType < len(m.testTypes)-1 {
 				m.testType += 1
 			} else {
 				m.testType = 0
 			}
 		case tcell.KeyDown:
 			m.inPrompt = true
-			TestTypes[m.testType].Update(k)
+			m.testTypes[m.testType].Update(k)
 		}
 
 	}
 	return nil
 }
 
+// updateImport edits the pasted code and starts its test on enter. Enter on
+// an empty box closes it.
+func (m *Menu) updateImport(k *tcell.EventKey) Drawable {
+	switch k.Key() {
+	case tcell.KeyEnter:
+		if m.code == "" {
+			m.importing = false
+			m.importErr = nil
+			return nil
+		}
+		code, err := DecodeShareCode(m.code)
+		if err != nil {
+			m.importErr = err
+			return nil
+		}
+		return NewSharedTest(m.screen, code)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.code) > 0 {
+			m.code = m.code[:len(m.code)-1]
+		}
+	case tcell.KeyRune:
+		m.code += string(k.Rune())
+	}
+	m.importErr = nil
+
+	return nil
+}
+
+func (m *Menu) drawImportBox(startingRow int) {
+	sWidth, _ := m.screen.Size()
+	boxWidth := sWidth / 2
+
+	title := "paste a test code and press enter, enter on an empty box to go back..."
+	drawTextCentered(m.screen, len(title), startingRow+1, title, AppTextStyle)
+
+	code := m.code + "_"
+	end := drawCode(m.screen, code, startingRow+3, boxWidth)
+	if m.importErr != nil {
+		msg := m.importErr.Error()
+		drawTextCentered(m.screen, len(msg), end+1, msg, WrongTextStyle)
+	}
+}
+
 func (m *Menu) drawChoiceBox(screen tcell.Screen, startingRow int) {
-	if len(TestTypes) == 0 {
+	if len(m.testTypes) == 0 {
 		return
 	}
 
@@ -108,8 +224,8 @@ func (m *Menu) drawChoiceBox(screen tcell.Screen, startingRow int) {
 	startWidth, _ := drawCenteredBox(screen, startingRow, boxWidth, 2, AppTextStyle, tcell.Style{})
 
 	totalSpace := boxWidth - 2
-	space := totalSpace / (len(TestTypes) + 1)
-	for i, ch := range TestTypes {
+	space := totalSpace / (len(m.testTypes) + 1)
+	for i, ch := range m.testTypes {
 		choice := ch.Name()
 		style := AppTextStyle
 		if i == m.testType {
@@ -142,269 +258,82 @@ func (m *Menu) drawChoiceBox(screen tcell.Screen, startingRow int) {
 		screen.SetContent(startWidth+boxWidth, i, tcell.RuneVLine, nil, AppYellowTextStyle)
 	}
 
-	TestTypes[m.testType].Draw(screen, startingRow+4, startWidth+1, boxWidth)
+	m.testTypes[m.testType].Draw(screen, startingRow+4, startWidth+1, boxWidth)
 }
 
-// TODO: dedup code for word and time prompts
+// WordPrompt picks the options and length of a words test.
 type WordPrompt struct {
-	includedWords []int
-	currWord      int
-
-	wordCount     int
-	currWordCount int
+	// the check list on the left, the word counts on the right
+	optionColumns
+}
 
-	inCheckList bool
-	inPrompt    bool
+func NewWordPrompt() *WordPrompt {
+	return &WordPrompt{optionColumns{
+		left:  NewCheckList(CheckListItems),
+		right: NewOptionList(WordCountChoices, 2),
+	}}
 }
 
 func (w *WordPrompt) Name() string {
 	return "word"
 }
 
-func (w *WordPrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
-	lineWidth := (boxWidth / 2) + startCol - 1
-
-	// draw first column
-	chCol := startCol - 6 + (lineWidth-startCol)/2
-	for i, t := range CheckListItems {
-		item := fmt.Sprintf("[] %s", t)
-		style := AppTextStyle
-		if Contains(w.includedWords, i) {
-			item = fmt.Sprintf("[%c] %s", 'X', t)
-		}
-		if i == w.currWord && w.inCheckList && w.inPrompt {
-			style = AppYellowTextStyle
-			item = string(tcell.RuneDiamond) + item
-		}
-		drawText(screen, len(item), chCol, startRow+((i+1)*3), item, style)
-	}
-
-	// draw vertical line
-	for i := startRow + 1; i < startRow+9; i++ {
-		screen.SetContent(lineWidth, i, tcell.RuneVLine, nil, AppYellowTextStyle)
-	}
-
-	// draw second column
-	dcCol := lineWidth + (startCol+boxWidth-lineWidth)/2
-	for i, t := range WordCountChoices {
-		item := t
-		style := AppTextStyle
-		if !w.inCheckList {
-			if i == w.wordCount {
-				style = AppYellowTextStyle
-			}
-			if i == w.currWordCount && w.inPrompt {
-				item = fmt.Sprintf("%c %s", tcell.RuneDiamond, t)
-			}
-		}
-		if i == w.wordCount {
-			style = AppYellowTextStyle
-		}
-
-		drawText(screen, len(item), dcCol, startRow+((i+1)*2), item, style)
-	}
-
+func (w *WordPrompt) Kind() int {
+	return TEST_WORD
 }
 
-func (w *WordPrompt) Update(event *tcell.EventKey) bool {
-	switch event.Key() {
-	case tcell.KeyDown:
-		if !w.inPrompt {
-			w.currWord = 0
-			w.inCheckList = true
-			w.inPrompt = true
-			return false
-		}
-
-		if w.inCheckList {
-			if w.currWord < len(CheckListItems)-1 {
-				w.currWord += 1
-			} else {
-				w.currWord = 0
-			}
-		} else {
-			if w.wordCount < len(WordCountChoices)-1 {
-				w.currWordCount += 1
-			} else {
-				w.currWordCount = 0
-			}
-		}
-	case tcell.KeyUp:
-		if w.inCheckList {
-			if w.currWord > 0 {
-				w.currWord -= 1
-			} else {
-				w.inPrompt = false
-				return true
-			}
-		} else {
-			if w.currWordCount > 0 {
-				w.currWordCount -= 1
-			} else {
-				w.inPrompt = false
-				return true
-			}
-		}
-	case tcell.KeyLeft, tcell.KeyRight:
-		w.inCheckList = !w.inCheckList
-	case tcell.KeyEnter:
-		if w.inCheckList {
-			if !Contains(w.includedWords, w.currWord) {
-				w.includedWords = append(w.includedWords, w.currWord)
-			} else {
-				newList := make([]int, 0)
-				for _, item := range w.includedWords {
-					if item != w.currWord {
-						newList = append(newList, item)
-					}
-				}
-				w.includedWords = newList
-			}
-		} else {
-			w.wordCount = w.currWordCount
-		}
-	}
-
-	return false
+func (w *WordPrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	lineWidth := (boxWidth / 2) + startCol - 1
+	w.left.Draw(screen, startCol-6+(lineWidth-startCol)/2, startRow, 3, w.focused(true))
+	drawDivider(screen, lineWidth, startRow)
+	w.right.Draw(screen, lineWidth+(startCol+boxWidth-lineWidth)/2, startRow, 2, w.focused(false))
 }
 
 func (w *WordPrompt) Config() Config {
-	wc, _ := strconv.Atoi(WordCountChoices[w.wordCount])
+	wc, _ := strconv.Atoi(WordCountChoices[w.right.selected])
 	return Config{
 		Words:       wc,
-		Punctuation: Contains(w.includedWords, PUNCTUATION),
-		Number:      Contains(w.includedWords, NUMBER),
+		Punctuation: w.left.Checked(PUNCTUATION),
+		Number:      w.left.Checked(NUMBER),
+		Blind:       w.left.Checked(BLIND),
 	}
 }
 
+// TimePrompt picks the options and duration of a time test.
 type TimePrompt struct {
-	includedWords []int
-	currWord      int
-
-	duration     int
-	currDuration int
+	// the check list on the left, the durations on the right
+	optionColumns
+}
 
-	inCheckList bool
-	inPrompt    bool
+func NewTimePrompt() *TimePrompt {
+	return &TimePrompt{optionColumns{
+		left:  NewCheckList(CheckListItems),
+		right: NewOptionList(DurationChoices, 2),
+	}}
 }
 
 func (w *TimePrompt) Name() string {
 	return "time"
 }
 
-func (w *TimePrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
-	lineWidth := (boxWidth / 2) + startCol - 1
-
-	// draw first column
-	chCol := startCol - 6 + (lineWidth-startCol)/2
-	for i, t := range CheckListItems {
-		item := fmt.Sprintf("[] %s", t)
-		style := AppTextStyle
-		if Contains(w.includedWords, i) {
-			item = fmt.Sprintf("[%c] %s", 'X', t)
-		}
-		if i == w.currWord && w.inCheckList && w.inPrompt {
-			style = AppYellowTextStyle
-			item = string(tcell.RuneDiamond) + item
-		}
-		drawText(screen, len(item), chCol, startRow+((i+1)*3), item, style)
-	}
-
-	// draw vertical line
-	for i := startRow + 1; i < startRow+9; i++ {
-		screen.SetContent(lineWidth, i, tcell.RuneVLine, nil, AppYellowTextStyle)
-	}
-
-	// draw second column
-	dcCol := lineWidth + (startCol+boxWidth-lineWidth)/2
-	for i, t := range DurationChoices {
-		item := t
-		style := AppTextStyle
-		if !w.inCheckList {
-			if i == w.duration {
-				style = AppYellowTextStyle
-			}
-			if i == w.currDuration && w.inPrompt {
-				item = fmt.Sprintf("%c %s", tcell.RuneDiamond, t)
-			}
-		}
-		if i == w.duration {
-			style = AppYellowTextStyle
-		}
-
-		drawText(screen, len(item), dcCol, startRow+((i+1)*2), item, style)
-	}
-
+func (w *TimePrompt) Kind() int {
+	return TEST_TIME
 }
 
-func (w *TimePrompt) Update(event *tcell.EventKey) bool {
-	switch event.Key() {
-	case tcell.KeyDown:
-		if !w.inPrompt {
-			w.currWord = 0
-			w.inCheckList = true
-			w.inPrompt = true
-			return false
-		}
-
-		if w.inCheckList {
-			if w.currWord < len(CheckListItems)-1 {
-				w.currWord += 1
-			} else {
-				w.currWord = 0
-			}
-		} else {
-			if w.duration < len(DurationChoices)-1 {
-				w.currDuration += 1
-			} else {
-				w.currDuration = 0
-			}
-		}
-	case tcell.KeyUp:
-		if w.inCheckList {
-			if w.currWord > 0 {
-				w.currWord -= 1
-			} else {
-				w.inPrompt = false
-				return true
-			}
-		} else {
-			if w.currDuration > 0 {
-				w.currDuration -= 1
-			} else {
-				w.inPrompt = false
-				return true
-			}
-		}
-	case tcell.KeyLeft, tcell.KeyRight:
-		w.inCheckList = !w.inCheckList
-	case tcell.KeyEnter:
-		if w.inCheckList {
-			if !Contains(w.includedWords, w.currWord) {
-				w.includedWords = append(w.includedWords, w.currWord)
-			} else {
-				newList := make([]int, 0)
-				for _, item := range w.includedWords {
-					if item != w.currWord {
-						newList = append(newList, item)
-					}
-				}
-				w.includedWords = newList
-			}
-		} else {
-			w.duration = w.currDuration
-		}
-	}
-
-	return false
+func (w *TimePrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	lineWidth := (boxWidth / 2) + startCol - 1
+	w.left.Draw(screen, startCol-6+(lineWidth-startCol)/2, startRow, 3, w.focused(true))
+	drawDivider(screen, lineWidth, startRow)
+	w.right.Draw(screen, lineWidth+(startCol+boxWidth-lineWidth)/2, startRow, 2, w.focused(false))
 }
 
 func (w *TimePrompt) Config() Config {
-	d, _ := strconv.Atoi(DurationChoices[w.duration])
+	d, _ := strconv.Atoi(DurationChoices[w.right.selected])
 	return Config{
 		Duration:    d,
-		Punctuation: Contains(w.includedWords, PUNCTUATION),
-		Number:      Contains(w.includedWords, NUMBER),
+		Punctuation: w.left.Checked(PUNCTUATION),
+		Number:      w.left.Checked(NUMBER),
+		Blind:       w.left.Checked(BLIND),
 	}
 }
 
@@ -418,6 +347,10 @@ func (w *QuotePrompt) Name() string {
 	return "quote"
 }
 
+func (w *QuotePrompt) Kind() int {
+	return TEST_QUOTE
+}
+
 func (w *QuotePrompt) Update(event *tcell.EventKey) bool {
 	switch event.Key() {
 	case tcell.KeyUp:
@@ -456,3 +389,136 @@ func (w *QuotePrompt) Config() Config {
 		QuoteLen: w.qType,
 	}
 }
+
+// PracticePrompt sets up an adaptive test and explains which keys it's
+// currently targeting.
+type PracticePrompt struct {
+	targets    []Target
+	wordCounts OptionList
+
+	inPrompt bool
+}
+
+func NewPracticePrompt() *PracticePrompt {
+	return &PracticePrompt{wordCounts: NewOptionList(WordCountChoices, 2)}
+}
+
+func (w *PracticePrompt) Name() string {
+	return "practice"
+}
+
+func (w *PracticePrompt) Kind() int {
+	return TEST_PRACTICE
+}
+
+func (w *PracticePrompt) Load(store Store) {
+	stats, _ := LoadKeyStats(store)
+	w.targets = weakTargets(stats)
+}
+
+func (w *PracticePrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	lineWidth := (boxWidth / 2) + startCol - 1
+
+	// draw first column
+	chCol := startCol + 2
+	if len(w.targets) == 0 {
+		drawText(screen, lineWidth-chCol-1, chCol, startRow+1, "not enough key stats yet, words are picked evenly", AppTextStyle)
+	} else {
+		drawText(screen, lineWidth-chCol, chCol, startRow+1, "targeting:", AppYellowTextStyle)
+		for i, t := range w.targets {
+			item := t.Describe()
+			drawText(screen, len(item), chCol, startRow+2+i, item, AppTextStyle)
+		}
+	}
+
+	drawDivider(screen, lineWidth, startRow)
+	w.wordCounts.Draw(screen, lineWidth+(startCol+boxWidth-lineWidth)/2, startRow, 2, w.inPrompt)
+}
+
+func (w *PracticePrompt) Update(event *tcell.EventKey) bool {
+	switch event.Key() {
+	case tcell.KeyDown:
+		if !w.inPrompt {
+			w.wordCounts.curr = w.wordCounts.selected
+			w.inPrompt = true
+			return false
+		}
+		w.wordCounts.Down()
+	case tcell.KeyUp:
+		if w.wordCounts.Up() {
+			w.inPrompt = false
+			return true
+		}
+	case tcell.KeyEnter:
+		w.wordCounts.Enter()
+	}
+
+	return false
+}
+
+func (w *PracticePrompt) Config() Config {
+	wc, _ := strconv.Atoi(WordCountChoices[w.wordCounts.selected])
+	return Config{
+		Words: wc,
+	}
+}
+
+// LessonPrompt shows the progress of the letter unlocking lesson. Its
+// tests only use the unlocked letters.
+type LessonPrompt struct {
+	progress LessonProgress
+}
+
+func (w *LessonPrompt) Name() string {
+	return "lesson"
+}
+
+func (w *LessonPrompt) Kind() int {
+	return TEST_LESSON
+}
+
+func (w *LessonPrompt) Load(store Store) {
+	w.progress = LoadLessonProgress(store)
+}
+
+func (w *LessonPrompt) Draw(screen tcell.Screen, startRow, startCol, boxWidth int) {
+	progress := w.progress
+
+	header := fmt.Sprintf("unlocked %d/%d letters, reach %d wpm at %d%% on each to unlock the next",
+		progress.Unlocked, len(UNLOCK_ORDER), prefsOf(screen).LessonWpm, prefsOf(screen).LessonAccuracy)
+	drawTextCentered(screen, len(header), startRow+1, header, AppTextStyle)
+
+	// one column per letter, in unlocking order
+	letters := progress.Letters()
+	col := centerWidth(screen, len(UNLOCK_ORDER)*2)
+	for i, letter := range UNLOCK_ORDER {
+		style := TargetTextStyle
+		if i < len(letters) {
+			style = WrongTextStyle
+			if progress.Passed(string(letter), prefsOf(screen)) {
+				style = CorrectTextStyle
+			}
+		}
+		if string(letter) == progress.Focus() {
+			style = AppYellowTextStyle
+		}
+		screen.SetContent(col+i*2, startRow+3, letter, nil, style)
+	}
+
+	focus := progress.Focus()
+	detail := fmt.Sprintf("focusing on %s", focus)
+	if p, ok := progress.Stats[focus]; ok {
+		detail += fmt.Sprintf(": %.0f wpm, %.0f%% accuracy", p.Wpm, p.Accuracy)
+	}
+	drawTextCentered(screen, len(detail), startRow+5, detail, AppYellowTextStyle)
+}
+
+func (w *LessonPrompt) Update(event *tcell.EventKey) bool {
+	return event.Key() == tcell.KeyUp
+}
+
+func (w *LessonPrompt) Config() Config {
+	return Config{
+		Words: 25,
+	}
+}
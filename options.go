@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// OptionList is a column of options in a test prompt. The cursor moves
+// through it and enter picks the option under it or, in check lists, checks
+// or unchecks it.
+type OptionList struct {
+	items  []string
+	checks bool
+
+	curr     int
+	selected int
+	// the checked options of check lists, in the order they were checked
+	checked []int
+}
+
+func NewOptionList(items []string, selected int) OptionList {
+	return OptionList{
+		items:    items,
+		curr:     selected,
+		selected: selected,
+	}
+}
+
+func NewCheckList(items []string, checked ...int) OptionList {
+	return OptionList{
+		items:   items,
+		checks:  true,
+		checked: checked,
+	}
+}
+
+// Down moves the cursor down, back to the top after the last option.
+func (o *OptionList) Down() {
+	o.curr = (o.curr + 1) % len(o.items)
+}
+
+// Up moves the cursor up and reports whether it was already at the top.
+func (o *OptionList) Up() bool {
+	if o.curr == 0 {
+		return true
+	}
+	o.curr -= 1
+	return false
+}
+
+func (o *OptionList) Enter() {
+	if !o.checks {
+		o.selected = o.curr
+		return
+	}
+
+	if !o.Checked(o.curr) {
+		o.checked = append(o.checked, o.curr)
+		return
+	}
+	checked := make([]int, 0, len(o.checked))
+	for _, c := range o.checked {
+		if c != o.curr {
+			checked = append(checked, c)
+		}
+	}
+	o.checked = checked
+}
+
+func (o *OptionList) Checked(i int) bool {
+	return Contains(o.checked, i)
+}
+
+// Draw draws the options spacing rows apart below row, showing the cursor
+// when the list has the focus.
+func (o *OptionList) Draw(screen tcell.Screen, col, row, spacing int, focused bool) {
+	for i, t := range o.items {
+		item := t
+		style := AppTextStyle
+		if o.checks {
+			item = "[] " + t
+			if o.Checked(i) {
+				item = fmt.Sprintf("[%c] %s", 'X', t)
+			}
+			if i == o.curr && focused {
+				style = AppYellowTextStyle
+				item = string(tcell.RuneDiamond) + item
+			}
+		} else {
+			if i == o.selected {
+				style = AppYellowTextStyle
+			}
+			if i == o.curr && focused {
+				item = fmt.Sprintf("%c %s", tcell.RuneDiamond, t)
+			}
+		}
+
+		drawText(screen, len(item), col, row+(i+1)*spacing, item, style)
+	}
+}
+
+// optionColumns moves between two option lists drawn side by side: down
+// enters the left one, left and right switch between them and up past the
+// top leaves the prompt.
+type optionColumns struct {
+	left  OptionList
+	right OptionList
+
+	inLeft   bool
+	inPrompt bool
+}
+
+// Update reports whether the prompt was left.
+func (c *optionColumns) Update(event *tcell.EventKey) bool {
+	switch event.Key() {
+	case tcell.KeyDown:
+		if !c.inPrompt {
+			c.inLeft = true
+			c.inPrompt = true
+			return false
+		}
+		c.active().Down()
+	case tcell.KeyUp:
+		if c.active().Up() {
+			c.inPrompt = false
+			return true
+		}
+	case tcell.KeyLeft, tcell.KeyRight:
+		c.inLeft = !c.inLeft
+	case tcell.KeyEnter:
+		c.active().Enter()
+	}
+
+	return false
+}
+
+func (c *optionColumns) active() *OptionList {
+	if c.inLeft {
+		return &c.left
+	}
+	return &c.right
+}
+
+// focused reports whether the left or right column shows its cursor.
+func (c *optionColumns) focused(left bool) bool {
+	return c.inPrompt && c.inLeft == left
+}
+
+// drawDivider draws the line between the two columns of a prompt.
+func drawDivider(screen tcell.Screen, col, startRow int) {
+	for i := startRow + 1; i < startRow+9; i++ {
+		screen.SetContent(col, i, tcell.RuneVLine, nil, AppYellowTextStyle)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	PRACTICE_REPEATS = 3
+	// words typed slower than this share of the test's median burst are
+	// practiced along with the missed ones
+	SLOW_WORD_FACTOR = 0.7
+)
+
+// practiceWords picks the words of a test worth practicing: the ones typed
+// wrong and the ones typed much slower than the rest.
+func practiceWords(words []WordResult) (missed, slow []string) {
+	var bursts []float64
+	for _, w := range words {
+		if w.correct && w.burst > 0 {
+			bursts = append(bursts, w.burst)
+		}
+	}
+	sort.Float64s(bursts)
+
+	median := 0.0
+	if len(bursts) > 0 {
+		median = bursts[len(bursts)/2]
+	}
+
+	for _, w := range words {
+		if w.expected == "" {
+			continue
+		}
+		if !w.correct {
+			missed = append(missed, w.expected)
+		} else if w.burst > 0 && w.burst < median*SLOW_WORD_FACTOR {
+			slow = append(slow, w.expected)
+		}
+	}
+
+	return missed, slow
+}
+
+// practiceText repeats every distinct word a few times and shuffles them
+// into the text of a practice test.
+func practiceText(r *rand.Rand, words []string) string {
+	seen := map[string]bool{}
+	var selected []string
+	for _, w := range words {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		for i := 0; i < PRACTICE_REPEATS; i++ {
+			selected = append(selected, w)
+		}
+	}
+
+	r.Shuffle(len(selected), func(i, j int) {
+		selected[i], selected[j] = selected[j], selected[i]
+	})
+
+	return strings.Join(selected, " ")
+}
+
+// historyPracticeWords gathers the missed and slow words of the last n
+// tests in the history.
+func historyPracticeWords(store Store, n int) []string {
+	entries, _ := LoadHistory(store)
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+
+	var words []string
+	for _, e := range entries {
+		words = append(words, e.Missed...)
+		words = append(words, e.Slow...)
+	}
+
+	return words
+}
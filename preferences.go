@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const (
+	APP_DIR       = "monkeytype"
+	SETTINGS_FILE = "settings.json"
+)
+
+const (
+	SOUND_OFF int = iota
+	SOUND_ERRORS
+	SOUND_ALL
+)
+
+const (
+	CONFIDENCE_NORMAL int = iota
+	CONFIDENCE_KEEP_CORRECT
+	CONFIDENCE_MAX
+)
+
+const (
+	STOP_OFF int = iota
+	STOP_ON_LETTER
+	STOP_ON_WORD
+)
+
+const (
+	DIFFICULTY_NORMAL int = iota
+	DIFFICULTY_EXPERT
+	DIFFICULTY_MASTER
+)
+
+var (
+	DifficultyChoices  = []string{"normal", "expert", "master"}
+	StopOnErrorChoices = []string{"off", "letter", "word"}
+	SoundChoices       = []string{"off", "errors", "every key"}
+	ConfidenceChoices  = []string{"normal", "keep correct words", "no backspace"}
+)
+
+type Preferences struct {
+	TapeMode  bool `json:"tape_mode"`
+	LiveWpm   bool `json:"live_wpm"`
+	TextWidth int  `json:"text_width"`
+
+	CaretStyle int `json:"caret_style"`
+	CaretMode  int `json:"caret_mode"`
+
+	HideExtra   bool `json:"hide_extra"`
+	Difficulty  int  `json:"difficulty"`
+	StopOnError int  `json:"stop_on_error"`
+	StrictSpace bool `json:"strict_space"`
+	MinWpm      int  `json:"min_wpm"`
+	MinAccuracy int  `json:"min_accuracy"`
+	MinBurst    int  `json:"min_burst"`
+
+	PracticeTests int `json:"practice_tests"`
+
+	LessonWpm      int `json:"lesson_wpm"`
+	LessonAccuracy int `json:"lesson_accuracy"`
+
+	Sound int `json:"sound"`
+
+	QuickRestart bool `json:"quick_restart"`
+	Confidence   int  `json:"confidence"`
+	// the name of the keyboard layout, so adding or removing user layouts
+	// doesn't change it
+	Layout string `json:"layout"`
+
+	Username    string `json:"username"`
+	Leaderboard string `json:"leaderboard"`
+}
+
+// Prefs are the settings of whoever runs the app. Remote users have their
+// own, see prefsOf.
+var Prefs = LoadPreferences(LocalStore)
+
+func DefaultPreferences() Preferences {
+	return Preferences{
+		TextWidth:      50,
+		PracticeTests:  10,
+		LessonWpm:      35,
+		LessonAccuracy: 95,
+		CaretStyle:     CARET_LINE,
+		CaretMode:      CARET_MODE_CURSOR,
+		Sound:          SOUND_OFF,
+		Layout:         Layouts[0].Name,
+	}
+}
+
+// LoadPreferences reads the saved settings, falling back to the defaults for
+// anything that is missing or unreadable. Values out of the range the
+// settings screen allows are brought back in it.
+func LoadPreferences(store Store) Preferences {
+	p := DefaultPreferences()
+
+	path, err := store.path(SETTINGS_FILE)
+	if err != nil {
+		return p
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return p
+	}
+	_ = json.Unmarshal(content, &p)
+
+	for _, group := range SettingGroups {
+		for _, item := range group.items {
+			item.Clamp(&p)
+		}
+	}
+
+	return p
+}
+
+func (p Preferences) Save(store Store) error {
+	path, err := store.path(SETTINGS_FILE)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
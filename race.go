@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+const LANE_NAME_WIDTH = 12
+
+var _ tcell.Event = (*EventRace)(nil)
+
+// EventRace is posted when the opponents of a race change, so the watching
+// Drawable gets redrawn. Like EventTick it's only delivered to its source.
+type EventRace struct {
+	when   time.Time
+	source Drawable
+}
+
+func (e *EventRace) When() time.Time {
+	return e.when
+}
+
+// Lane is the state of one player of a race.
+type Lane struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Progress float64 `json:"progress"`
+	Wpm      int     `json:"wpm"`
+	// the place the player finished at, zero while still racing
+	Place int  `json:"place,omitempty"`
+	Left  bool `json:"left,omitempty"`
+}
+
+// Race is a test taken against opponents.
+type Race interface {
+	// Watch makes the race notify view of any change with an EventRace.
+	Watch(view Drawable)
+	// Report tells the opponents how far into the text, from 0 to 1, the
+	// player is and at what speed.
+	Report(progress float64, wpm int)
+	Finish(wpm int)
+	// Leave quits the race, a finished one included.
+	Leave()
+
+	// Lanes returns the opponents.
+	Lanes() []Lane
+	// Standings returns every player, the user included, best first.
+	Standings() []Lane
+}
+
+// NewRaceTest creates a word test over the race's text, with the opponents
+// shown above it.
+func NewRaceTest(screen tcell.Screen, race Race, text string, config Config) Drawable {
+	return &Test{
+		screen: screen,
+		kind:   TEST_WORD,
+		config: config,
+		seed:   config.Seed,
+		txt:    text,
+		race:   race,
+		bursts: map[int]float64{},
+
+		givenText: true,
+
+		keyStats: KeyStats{},
+	}
+}
+
+// sortStandings orders finished players by place, then the others by
+// progress, with the ones that left last.
+func sortStandings(lanes []Lane) {
+	sort.SliceStable(lanes, func(i, j int) bool {
+		a, b := lanes[i], lanes[j]
+		if a.Left != b.Left {
+			return b.Left
+		}
+		if (a.Place > 0) != (b.Place > 0) {
+			return a.Place > 0
+		}
+		if a.Place > 0 {
+			return a.Place < b.Place
+		}
+		return a.Progress > b.Progress
+	})
+}
+
+// drawLanes draws a progress bar per opponent and returns the row below
+// them.
+func drawLanes(screen tcell.Screen, lanes []Lane, startW, startH, width int) int {
+	barWidth := width - LANE_NAME_WIDTH - 10
+	for i, lane := range lanes {
+		h := startH + i
+
+		name := lane.Name
+		if len(name) > LANE_NAME_WIDTH-1 {
+			name = name[:LANE_NAME_WIDTH-1]
+		}
+		drawText(screen, len(name), startW, h, name, AppTextStyle)
+
+		filled := int(lane.Progress * float64(barWidth))
+		for x := 0; x < barWidth; x++ {
+			ch, style := '·', TargetTextStyle
+			if x < filled {
+				ch, style = '━', CorrectTextStyle
+			}
+			screen.SetContent(startW+LANE_NAME_WIDTH+x, h, ch, nil, style)
+		}
+
+		status := fmt.Sprintf("%d wpm", lane.Wpm)
+		style := AppTextStyle
+		switch {
+		case lane.Left:
+			status, style = "left", TargetTextStyle
+		case lane.Place > 0:
+			status, style = ordinal(lane.Place), AppYellowTextStyle
+		}
+		drawText(screen, len(status), startW+LANE_NAME_WIDTH+barWidth+2, h, status, style)
+	}
+
+	return startH + len(lanes)
+}
+
+// describeStandings lists the final places on a single line.
+func describeStandings(lanes []Lane) string {
+	places := make([]string, len(lanes))
+	for i, lane := range lanes {
+		switch {
+		case lane.Left:
+			places[i] = fmt.Sprintf("%s left", lane.Name)
+		case lane.Place > 0:
+			places[i] = fmt.Sprintf("%s %s %d wpm", ordinal(lane.Place), lane.Name, lane.Wpm)
+		default:
+			places[i] = fmt.Sprintf("%s racing", lane.Name)
+		}
+	}
+	return strings.Join(places, ", ")
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -16,10 +17,59 @@ const RES_TITLE = ` _____                            ___    __
     \/_/\/ /\/____/\/___/  \/___/  \/____/ \/__/
 `
 
+// tests failed on the first keys are scored as if they took this long, so
+// their wpm doesn't go through the roof
+const MIN_SCORED_DURATION = time.Second
+
 type Metric struct {
+	kind   int
+	config Config
+	seed   int64
+
 	duration     time.Duration
+	paused       time.Duration
 	allChars     int
 	correctChars int
+	text         string
+	givenText    bool
+	typed        string
+	words        []WordResult
+	keystrokes   int
+	mistakes     int
+	keyStats     KeyStats
+	samples      []Sample
+
+	difficulty  int
+	stopOnError int
+	strictSpace bool
+
+	failed     bool
+	failReason string
+
+	race Race
+}
+
+// activeDuration is the time spent typing, without the time paused.
+func (m Metric) activeDuration() time.Duration {
+	return m.duration - m.paused
+}
+
+// rules describes the error handling the test was taken with.
+func (m Metric) rules() string {
+	var rules []string
+	if m.difficulty != DIFFICULTY_NORMAL {
+		rules = append(rules, DifficultyChoices[m.difficulty])
+	}
+	if m.stopOnError != STOP_OFF {
+		rules = append(rules, "stop on "+StopOnErrorChoices[m.stopOnError])
+	}
+	if m.strictSpace {
+		rules = append(rules, "strict space")
+	}
+	if m.config.Blind {
+		rules = append(rules, "blind")
+	}
+	return strings.Join(rules, ", ")
 }
 
 var _ Drawable = (*Result)(nil)
@@ -27,10 +77,19 @@ var _ Drawable = (*Result)(nil)
 type Result struct {
 	screen  tcell.Screen
 	metrics Metric
+	review  *ReviewPanel
 
-	rawWpm   int
-	wpm      int
-	accuracy int
+	missed []string
+	slow   []string
+
+	unlocked string
+	lesson   string
+	code     string
+
+	rawWpm      int
+	wpm         int
+	accuracy    int
+	consistency int
 }
 
 func NewResult(screen tcell.Screen, metrics Metric) Drawable {
@@ -43,45 +102,222 @@ func NewResult(screen tcell.Screen, metrics Metric) Drawable {
 func (r *Result) Init() {
 	r.rawWpm, r.wpm = r.calcWpm()
 	r.accuracy = r.calcAccuracy()
+	r.consistency = consistency(r.metrics.samples)
+	r.review = NewReviewPanel(r.metrics)
+	r.missed, r.slow = practiceWords(r.metrics.words)
+
+	store := storeOf(r.screen)
+	_ = AppendHistory(store, r.historyEntry())
+	_ = AppendKeyStats(store, r.metrics.keyStats)
+	// time tests left running without typing aren't scores
+	if mode, ok := leaderboardMode(r.metrics.kind, r.metrics.config, r.metrics.givenText); ok && !r.metrics.failed && r.metrics.allChars > 0 {
+		_ = AppendLeaderboard(LeaderboardEntry{
+			Date:        time.Now(),
+			User:        usernameOf(r.screen),
+			Key:         sessionOf(r.screen).key,
+			Mode:        mode,
+			Wpm:         r.wpm,
+			RawWpm:      r.rawWpm,
+			Accuracy:    r.accuracy,
+			Consistency: r.consistency,
+		})
+	}
+
+	// imported lessons may carry any text, so they don't count towards them
+	if r.metrics.kind == TEST_LESSON && !r.metrics.failed && !r.metrics.givenText {
+		progress := LoadLessonProgress(store)
+		r.unlocked, _ = progress.Record(store, r.metrics.keyStats, prefsOf(r.screen))
+	}
+	if r.metrics.kind == TEST_CURRICULUM && !r.metrics.givenText {
+		r.lesson = r.checkLesson()
+	}
+	if r.metrics.race != nil {
+		r.metrics.race.Watch(r)
+		if !r.metrics.failed {
+			r.metrics.race.Finish(r.wpm)
+		}
+	}
+}
+
+// checkLesson marks a curriculum lesson as passed when its goals were met
+// and describes the outcome.
+func (r *Result) checkLesson() string {
+	lesson := Curriculum[r.metrics.config.Lesson]
+	if r.metrics.failed || r.wpm < lesson.wpm || r.accuracy < lesson.accuracy {
+		return fmt.Sprintf("%s not passed yet, it needs %d wpm at %d%%", lesson.name, lesson.wpm, lesson.accuracy)
+	}
+
+	store := storeOf(r.screen)
+	progress := LoadCurriculumProgress(store)
+	progress[lesson.name] = true
+	_ = progress.Save(store)
+
+	return fmt.Sprintf("%s passed!", lesson.name)
+}
+
+func (r *Result) historyEntry() HistoryEntry {
+	return HistoryEntry{
+		Date:        time.Now(),
+		Kind:        r.metrics.kind,
+		Config:      r.metrics.config,
+		Seed:        r.metrics.seed,
+		GivenText:   r.metrics.givenText,
+		Wpm:         r.wpm,
+		RawWpm:      r.rawWpm,
+		Accuracy:    r.accuracy,
+		Consistency: r.consistency,
+		Seconds:     r.metrics.activeDuration().Seconds(),
+		Difficulty:  r.metrics.difficulty,
+		StopOnError: r.metrics.stopOnError,
+		StrictSpace: r.metrics.strictSpace,
+		Failed:      r.metrics.failed,
+		FailReason:  r.metrics.failReason,
+		Missed:      r.missed,
+		Slow:        r.slow,
+	}
 }
 
 func (r *Result) Draw() {
 	drawTitle(r.screen, RES_TITLE)
-	drawDashedBox(r.screen, r.wpm, r.accuracy, int(r.metrics.duration.Seconds()), r.rawWpm)
+	swidth, sheight := r.screen.Size()
+
+	// the unlock, lesson and race messages each get rows of their own above
+	// the scores, which move down to make room
+	var messages []string
+	if r.unlocked != "" {
+		messages = append(messages, splitTextIntoLines("new letter unlocked: "+r.unlocked, swidth-4)...)
+	}
+	if r.lesson != "" {
+		messages = append(messages, splitTextIntoLines(r.lesson, swidth-4)...)
+	}
+	if r.metrics.race != nil {
+		messages = append(messages, splitTextIntoLines(describeStandings(r.metrics.race.Standings()), swidth-4)...)
+	}
+	for i, line := range messages {
+		drawTextCentered(r.screen, len(line), 8+i, line, AppYellowTextStyle)
+	}
+	top := 9 + max(len(messages)-1, 0)
 
-	txt := "press enter to continue or esc to exit..."
-	drawTextCentered(r.screen, len(txt), 15, txt, AppTextStyle)
+	if r.metrics.failed {
+		drawFailedBox(r.screen, top, r.metrics.failReason)
+	} else {
+		drawDashedBox(r.screen, top, r.wpm, r.accuracy, int(r.metrics.activeDuration().Seconds()), r.rawWpm)
+	}
+
+	txt := "press enter to continue, e to export the test or esc to exit..."
+	if prefsOf(r.screen).QuickRestart {
+		txt = "press enter to continue, tab to restart, e to export the test or esc to exit..."
+	}
+	drawTextCentered(r.screen, len(txt), top+6, txt, AppTextStyle)
+	if len(r.missed)+len(r.slow) > 0 {
+		practice := "press p to practice missed and slow words..."
+		drawTextCentered(r.screen, len(practice), top+7, practice, AppTextStyle)
+	}
+
+	if rules := r.metrics.rules(); rules != "" {
+		drawTextCentered(r.screen, len(rules), top+8, rules, TargetTextStyle)
+	}
+	if r.metrics.seed != 0 {
+		seed := fmt.Sprintf("seed: %d", r.metrics.seed)
+		drawTextCentered(r.screen, len(seed), top+9, seed, TargetTextStyle)
+	}
+
+	lineLen := swidth * prefsOf(r.screen).TextWidth / 100
+	if r.code != "" {
+		help := "send this code to take the same test, e to hide it..."
+		drawTextCentered(r.screen, len(help), top+10, help, TargetTextStyle)
+		drawCode(r.screen, r.code, top+12, lineLen)
+		return
+	}
+
+	// this is also where errors of blind tests are first shown
+	r.review.Draw(r.screen, centerWidth(r.screen, lineLen), top+10, lineLen, sheight-top-11)
 }
 
 func (r *Result) Update(e tcell.Event) (next Drawable) {
-	key := e.(*tcell.EventKey)
+	key, ok := e.(*tcell.EventKey)
+	if !ok {
+		// the race standings changed
+		return nil
+	}
+	if r.review.Update(key) {
+		return nil
+	}
+	if r.metrics.race != nil {
+		defer func() {
+			if next != nil {
+				r.metrics.race.Leave()
+			}
+		}()
+	}
 	if key.Key() == tcell.KeyEnter {
 		return NewMenu(r.screen)
 	}
+	if key.Key() == tcell.KeyRune && key.Rune() == 'e' {
+		r.toggleCode()
+		return nil
+	}
+	if _, lan := r.metrics.race.(*NetRace); key.Key() == tcell.KeyTab && prefsOf(r.screen).QuickRestart && !lan {
+		return restartTest(r.screen, r.metrics.kind, r.metrics.config, r.metrics.seed, r.metrics.text, r.metrics.givenText)
+	}
+	if key.Key() == tcell.KeyRune && key.Rune() == 'p' && len(r.missed)+len(r.slow) > 0 {
+		return NewPracticeTest(r.screen, append(r.missed, r.slow...))
+	}
 	return nil
 }
 
+// toggleCode shows or hides the code others can take the test with.
+func (r *Result) toggleCode() {
+	if r.code != "" {
+		r.code = ""
+		return
+	}
+
+	code := ShareCode{
+		Kind:   r.metrics.kind,
+		Config: r.metrics.config,
+		Text:   r.metrics.text,
+		Seed:   r.metrics.seed,
+	}
+	r.code, _ = code.Encode()
+}
+
+// calcWpm counts the characters typed, skipped letters included, so time
+// and failed tests are scored on what was typed rather than the text
+// generated.
 func (r *Result) calcWpm() (int, int) {
-	raw := (float64(r.metrics.allChars) / 5) / r.metrics.duration.Minutes()
+	if r.metrics.allChars == 0 {
+		return 0, 0
+	}
+	raw := (float64(r.metrics.allChars) / 5) / max(r.metrics.activeDuration(), MIN_SCORED_DURATION).Minutes()
 	adjusted := raw * (float64(r.metrics.correctChars) / float64(r.metrics.allChars))
 	return int(raw), int(adjusted)
 }
 
+// calcAccuracy uses keystrokes when available so keys rejected by the stop
+// on error rules still count as mistakes.
 func (r *Result) calcAccuracy() int {
+	if r.metrics.keystrokes > 0 {
+		return int(float64(r.metrics.keystrokes-r.metrics.mistakes) / float64(r.metrics.keystrokes) * 100.0)
+	}
+	if r.metrics.allChars == 0 {
+		return 0
+	}
 	return int(float64(r.metrics.correctChars) / float64(r.metrics.allChars) * 100.0)
 }
 
-func drawDashedBox(screen tcell.Screen, wpm, accuracy, duration, raw int) {
+// drawDashedBox draws the scores in a box of six rows from top.
+func drawDashedBox(screen tcell.Screen, top, wpm, accuracy, duration, raw int) {
 	swidth, _ := screen.Size()
 	boxLen := swidth / 2
 	startWidth := (swidth - boxLen) / 2
 	space := boxLen / 3
 
 	for i := startWidth; i < startWidth+boxLen; i += 2 {
-		screen.SetContent(i, 9, tcell.RuneHLine, nil, AppYellowTextStyle)
-		screen.SetContent(i, 14, tcell.RuneHLine, nil, AppYellowTextStyle)
+		screen.SetContent(i, top, tcell.RuneHLine, nil, AppYellowTextStyle)
+		screen.SetContent(i, top+5, tcell.RuneHLine, nil, AppYellowTextStyle)
 	}
-	for i := 10; i <= 13; i += 2 {
+	for i := top + 1; i <= top+4; i += 2 {
 		screen.SetContent(startWidth, i, tcell.RuneVLine, nil, AppYellowTextStyle)
 		screen.SetContent(startWidth+boxLen-1, i, tcell.RuneVLine, nil, AppYellowTextStyle)
 	}
@@ -96,15 +332,30 @@ func drawDashedBox(screen tcell.Screen, wpm, accuracy, duration, raw int) {
 	rv := fmt.Sprintf("%d", raw)
 	innerStartWidth := (startWidth + (boxLen-space-len(wf)-len(wv)-len(rf)-len(rv))/2)
 
-	drawText(screen, len(af), innerStartWidth+3, 11, af, AppTextStyle)
-	drawText(screen, len(av), innerStartWidth+3+len(af), 11, av, AppYellowTextStyle)
+	drawText(screen, len(af), innerStartWidth+3, top+2, af, AppTextStyle)
+	drawText(screen, len(av), innerStartWidth+3+len(af), top+2, av, AppYellowTextStyle)
+
+	drawText(screen, len(wf), innerStartWidth+3, top+3, wf, AppTextStyle)
+	drawText(screen, len(wv), innerStartWidth+8+len(wf), top+3, wv, AppYellowTextStyle)
 
-	drawText(screen, len(wf), innerStartWidth+3, 12, wf, AppTextStyle)
-	drawText(screen, len(wv), innerStartWidth+8+len(wf), 12, wv, AppYellowTextStyle)
+	drawText(screen, len(tf), innerStartWidth+space+3, top+2, tf, AppTextStyle)
+	drawText(screen, len(tv), innerStartWidth+4+space+len(wf), top+2, tv, AppYellowTextStyle)
 
-	drawText(screen, len(tf), innerStartWidth+space+3, 11, tf, AppTextStyle)
-	drawText(screen, len(tv), innerStartWidth+4+space+len(wf), 11, tv, AppYellowTextStyle)
+	drawText(screen, len(rf), innerStartWidth+space+3, top+3, rf, AppTextStyle)
+	drawText(screen, len(rv), innerStartWidth+space+4+len(wf), top+3, rv, AppYellowTextStyle)
+}
+
+func drawFailedBox(screen tcell.Screen, top int, reason string) {
+	swidth, _ := screen.Size()
+	boxLen := swidth / 2
+	startWidth := (swidth - boxLen) / 2
+
+	for i := startWidth; i < startWidth+boxLen; i += 2 {
+		screen.SetContent(i, top, tcell.RuneHLine, nil, WrongTextStyle)
+		screen.SetContent(i, top+5, tcell.RuneHLine, nil, WrongTextStyle)
+	}
 
-	drawText(screen, len(rf), innerStartWidth+space+3, 12, rf, AppTextStyle)
-	drawText(screen, len(rv), innerStartWidth+space+4+len(wf), 12, rv, AppYellowTextStyle)
+	title := "test failed"
+	drawTextCentered(screen, len(title), top+2, title, WrongTextStyle)
+	drawTextCentered(screen, len(reason), top+3, reason, AppTextStyle)
 }
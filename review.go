@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// WordResult pairs a word of the text with what was typed for it.
+type WordResult struct {
+	expected string
+	typed    string
+	correct  bool
+	burst    float64
+}
+
+// ReviewPanel shows the text of a finished test with the mistyped words
+// highlighted, along with what was typed for the selected one.
+type ReviewPanel struct {
+	words    []WordResult
+	styled   [][]styledRune
+	mistakes []int
+
+	selected int
+	scroll   int
+	follow   bool
+}
+
+func NewReviewPanel(m Metric) *ReviewPanel {
+	// every mistake is shown here, extra letters included
+	styled, _, _ := styleWords(m.text, m.typed, false, false)
+
+	var mistakes []int
+	for i, w := range m.words {
+		if !w.correct && i < len(styled) {
+			mistakes = append(mistakes, i)
+		}
+	}
+
+	return &ReviewPanel{
+		words:    m.words,
+		styled:   styled,
+		mistakes: mistakes,
+		follow:   true,
+	}
+}
+
+// positions lays the words out in lines of lineLen and returns the line and
+// column of each one along with the number of lines.
+func (p *ReviewPanel) positions(lineLen int) ([][2]int, int) {
+	pos := make([][2]int, len(p.styled))
+	line, col := 0, 0
+	for i, word := range p.styled {
+		if col > 0 && col+1+len(word) > lineLen {
+			line++
+			col = 0
+		}
+		if col > 0 {
+			col++
+		}
+		pos[i] = [2]int{line, col}
+		col += len(word)
+	}
+
+	return pos, line + 1
+}
+
+func (p *ReviewPanel) Draw(screen tcell.Screen, startW, startH, width, height int) {
+	if height < 2 || len(p.styled) == 0 {
+		return
+	}
+
+	detail := "no mistakes, well done!"
+	if len(p.mistakes) > 0 {
+		w := p.words[p.mistakes[p.selected]]
+		detail = fmt.Sprintf("%d/%d expected: %s, typed: %s (left/right to review, up/down to scroll)",
+			p.selected+1, len(p.mistakes), w.expected, w.typed)
+	}
+	drawText(screen, len(detail), startW, startH, detail, AppYellowTextStyle)
+	startH++
+	height--
+
+	pos, lines := p.positions(width)
+	if p.follow && len(p.mistakes) > 0 {
+		line := pos[p.mistakes[p.selected]][0]
+		if line < p.scroll {
+			p.scroll = line
+		} else if line >= p.scroll+height {
+			p.scroll = line - height + 1
+		}
+		p.follow = false
+	}
+	if p.scroll > lines-height {
+		p.scroll = max(lines-height, 0)
+	}
+
+	mistakes := map[int]bool{}
+	for _, i := range p.mistakes {
+		mistakes[i] = true
+	}
+
+	for i, word := range p.styled {
+		line := pos[i][0] - p.scroll
+		if line < 0 || line >= height {
+			continue
+		}
+
+		for j, r := range word {
+			style := r.style
+			if mistakes[i] {
+				style = style.Underline(true)
+			}
+			if len(p.mistakes) > 0 && i == p.mistakes[p.selected] {
+				style = style.Reverse(true)
+			}
+			screen.SetContent(startW+pos[i][1]+j, startH+line, r.ch, nil, style)
+		}
+	}
+}
+
+// Update handles the review keys and reports whether the key was used.
+func (p *ReviewPanel) Update(key *tcell.EventKey) bool {
+	switch key.Key() {
+	case tcell.KeyLeft:
+		if p.selected > 0 {
+			p.selected -= 1
+			p.follow = true
+		}
+	case tcell.KeyRight:
+		if p.selected < len(p.mistakes)-1 {
+			p.selected += 1
+			p.follow = true
+		}
+	case tcell.KeyUp:
+		if p.scroll > 0 {
+			p.scroll -= 1
+		}
+	case tcell.KeyDown:
+		p.scroll += 1
+	default:
+		return false
+	}
+
+	return true
+}
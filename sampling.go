@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// THRESHOLD_GRACE is how long a test runs before the minimum speed,
+// accuracy and burst settings are enforced.
+const THRESHOLD_GRACE = 5 * time.Second
+
+var _ tcell.Event = (*EventTick)(nil)
+
+// EventTick is posted every second while a test is running. It's only
+// delivered to the Drawable that started the ticker.
+type EventTick struct {
+	when   time.Time
+	source Drawable
+}
+
+func (e *EventTick) When() time.Time {
+	return e.when
+}
+
+// Sample holds the running metrics of a test at one second.
+type Sample struct {
+	wpm        float64
+	raw        float64
+	accuracy   float64
+	keystrokes int
+}
+
+func (t *Test) startTicker() {
+	t.ticker = time.NewTicker(time.Second)
+	t.done = make(chan struct{})
+
+	go func(ticker *time.Ticker, done chan struct{}) {
+		for {
+			select {
+			case now := <-ticker.C:
+				_ = t.screen.PostEvent(&EventTick{when: now, source: t})
+			case <-done:
+				return
+			}
+		}
+	}(t.ticker, t.done)
+}
+
+func (t *Test) stopTicker() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+		close(t.done)
+		t.ticker = nil
+	}
+}
+
+// tick samples the running metrics once a second and ends the test when
+// time is up or a minimum threshold isn't met.
+func (t *Test) tick() Drawable {
+	if !t.clock.Started() || t.clock.IsPaused() {
+		return nil
+	}
+
+	t.samples = append(t.samples, t.sample())
+	if next := t.checkThresholds(); next != nil {
+		return next
+	}
+
+	return t.finish()
+}
+
+func (t *Test) sample() Sample {
+	minutes := t.clock.Elapsed().Minutes()
+	accuracy := 100.0
+	if t.keystrokes > 0 {
+		accuracy = float64(t.keystrokes-t.mistakes) / float64(t.keystrokes) * 100
+	}
+
+	return Sample{
+		wpm:        float64(t.correctChars()) / 5 / minutes,
+		raw:        float64(t.keystrokes) / 5 / minutes,
+		accuracy:   accuracy,
+		keystrokes: t.keystrokes,
+	}
+}
+
+// consistency rates, from 0 to 100, how steady the raw speed was from one
+// second to the next. The variation is mapped the way monkeytype does, so
+// the scores compare.
+func consistency(samples []Sample) int {
+	if len(samples) < 2 {
+		return 100
+	}
+
+	raws := make([]float64, len(samples))
+	mean := 0.0
+	for i, s := range samples {
+		keystrokes := s.keystrokes
+		if i > 0 {
+			keystrokes -= samples[i-1].keystrokes
+		}
+		raws[i] = float64(keystrokes) / 5 * 60
+		mean += raws[i]
+	}
+	mean /= float64(len(raws))
+	if mean == 0 {
+		return 0
+	}
+
+	variance := 0.0
+	for _, raw := range raws {
+		variance += (raw - mean) * (raw - mean)
+	}
+	cov := math.Sqrt(variance/float64(len(raws))) / mean
+
+	return int(math.Round(100 * (1 - math.Tanh(cov+math.Pow(cov, 3)/3+math.Pow(cov, 5)/5))))
+}
+
+func (t *Test) checkThresholds() Drawable {
+	if t.clock.Elapsed() < THRESHOLD_GRACE || len(t.samples) == 0 {
+		return nil
+	}
+
+	last := t.samples[len(t.samples)-1]
+	if t.prefs().MinWpm > 0 && last.wpm < float64(t.prefs().MinWpm) {
+		return t.fail(fmt.Sprintf("speed dropped below %d wpm", t.prefs().MinWpm))
+	}
+	if t.prefs().MinAccuracy > 0 && last.accuracy < float64(t.prefs().MinAccuracy) {
+		return t.fail(fmt.Sprintf("accuracy dropped below %d%%", t.prefs().MinAccuracy))
+	}
+
+	return nil
+}
+
+// checkBurst fails the test when the word that was just finished was typed
+// slower than the minimum burst.
+func (t *Test) checkBurst() Drawable {
+	if t.prefs().MinBurst == 0 || t.clock.Elapsed() < THRESHOLD_GRACE {
+		return nil
+	}
+
+	if t.lastBurst < float64(t.prefs().MinBurst) {
+		return t.fail(fmt.Sprintf("word burst below %d wpm", t.prefs().MinBurst))
+	}
+
+	return nil
+}
+
+// wordBurst returns the speed, in wpm, of typing `chars` characters since
+// the word was started.
+func (t *Test) wordBurst(chars int) float64 {
+	minutes := (t.clock.Elapsed() - t.wordStart).Minutes()
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(chars) / 5 / minutes
+}
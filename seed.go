@@ -0,0 +1,27 @@
+package main
+
+import (
+	"time"
+)
+
+const DAILY_WORDS = 50
+
+// FixedSeed is set with --seed and makes every test started from the menu
+// generate its text from it.
+var FixedSeed int64
+
+// dailySeed is the same for everyone on a given UTC day.
+func dailySeed(now time.Time) int64 {
+	y, m, d := now.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// dailyChallenge is a word test with punctuation, its text generated from
+// the day's seed.
+func dailyChallenge(now time.Time) Config {
+	return Config{
+		Words:       DAILY_WORDS,
+		Punctuation: true,
+		Seed:        dailySeed(now),
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Store is the directory a user's history, stats and progress are saved in.
+type Store string
+
+// LocalStore is the config directory of whoever runs the app. Settings and
+// layouts always live there.
+const LocalStore Store = ""
+
+// path returns the path of a file inside the store, creating the store's
+// directory if needed.
+func (s Store) path(name string) (string, error) {
+	dir := string(s)
+	if s == LocalStore {
+		config, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(config, APP_DIR)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// Session is the screen of one user of the app. It carries what belongs to
+// them alone, so several users can be served at once.
+type Session struct {
+	tcell.Screen
+	store Store
+	// the menu's test types, keeping what the user picked in them
+	testTypes []TestPrompt
+	// remote users keep their settings in their store, and can't change
+	// the ones only the machine's owner should, like the leaderboard's path
+	remote bool
+	prefs  *Preferences
+	// the name remote users are known by, local ones use the settings
+	user string
+	// the fingerprint of a remote user's public key
+	key string
+}
+
+func NewSession(screen tcell.Screen, store Store, remote bool) *Session {
+	prefs := &Prefs
+	if remote {
+		p := LoadPreferences(store)
+		prefs = &p
+	}
+
+	return &Session{
+		Screen:    screen,
+		store:     store,
+		testTypes: NewTestTypes(),
+		remote:    remote,
+		prefs:     prefs,
+	}
+}
+
+// sessionOf returns the session a screen belongs to, a new local one for
+// screens that aren't part of any.
+func sessionOf(screen tcell.Screen) *Session {
+	if s, ok := screen.(*Session); ok {
+		return s
+	}
+	return NewSession(screen, LocalStore, false)
+}
+
+func storeOf(screen tcell.Screen) Store {
+	return sessionOf(screen).store
+}
+
+// prefsOf returns the settings of the user of a screen.
+func prefsOf(screen tcell.Screen) *Preferences {
+	return sessionOf(screen).prefs
+}
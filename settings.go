@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// SettingItem is a single preference shown on the settings screen. It is a
+// toggle when `toggle` is set, free text when `text` is set, an enumeration
+// when `choices` is set and a number between `min` and `max` otherwise.
+// Enumerations are saved as the index of the choice in `value`, or as the
+// choice itself in `choice`.
+type SettingItem struct {
+	name    string
+	choices []string
+	min     int
+	max     int
+
+	toggle func(p *Preferences) *bool
+	text   func(p *Preferences) *string
+	value  func(p *Preferences) *int
+	choice func(p *Preferences) *string
+}
+
+const MAX_SETTING_TEXT = 200
+
+type SettingGroup struct {
+	name  string
+	items []SettingItem
+	// only the machine's owner can change these, not remote users
+	localOnly bool
+}
+
+var SettingGroups = []SettingGroup{
+	{name: "appearance", items: []SettingItem{
+		{name: "tape mode", toggle: func(p *Preferences) *bool { return &p.TapeMode }},
+		{name: "live wpm", toggle: func(p *Preferences) *bool { return &p.LiveWpm }},
+		{name: "text width %", min: 20, max: 100, value: func(p *Preferences) *int { return &p.TextWidth }},
+	}},
+	{name: "caret", items: []SettingItem{
+		{name: "style", choices: CaretStyles, value: func(p *Preferences) *int { return &p.CaretStyle }},
+		{name: "drawn with", choices: CaretModes, value: func(p *Preferences) *int { return &p.CaretMode }},
+	}},
+	{name: "behavior", items: []SettingItem{
+		{name: "hide extra letters", toggle: func(p *Preferences) *bool { return &p.HideExtra }},
+		{name: "difficulty", choices: DifficultyChoices, value: func(p *Preferences) *int { return &p.Difficulty }},
+		{name: "stop on error", choices: StopOnErrorChoices, value: func(p *Preferences) *int { return &p.StopOnError }},
+		{name: "strict space", toggle: func(p *Preferences) *bool { return &p.StrictSpace }},
+		{name: "min wpm", min: 0, max: 300, value: func(p *Preferences) *int { return &p.MinWpm }},
+		{name: "min accuracy %", min: 0, max: 100, value: func(p *Preferences) *int { return &p.MinAccuracy }},
+		{name: "min burst wpm", min: 0, max: 300, value: func(p *Preferences) *int { return &p.MinBurst }},
+		{name: "practice from last tests", min: 1, max: 100, value: func(p *Preferences) *int { return &p.PracticeTests }},
+	}},
+	{name: "lessons", items: []SettingItem{
+		{name: "unlock at wpm", min: 10, max: 200, value: func(p *Preferences) *int { return &p.LessonWpm }},
+		{name: "unlock at accuracy %", min: 50, max: 100, value: func(p *Preferences) *int { return &p.LessonAccuracy }},
+	}},
+	{name: "sound", items: []SettingItem{
+		{name: "beep on", choices: SoundChoices, value: func(p *Preferences) *int { return &p.Sound }},
+	}},
+	{name: "input", items: []SettingItem{
+		{name: "quick restart (tab)", toggle: func(p *Preferences) *bool { return &p.QuickRestart }},
+		{name: "confidence mode", choices: ConfidenceChoices, value: func(p *Preferences) *int { return &p.Confidence }},
+		{name: "keyboard layout", choices: LayoutNames, choice: func(p *Preferences) *string { return &p.Layout }},
+	}},
+	{name: "leaderboard", items: []SettingItem{
+		{name: "username", text: func(p *Preferences) *string { return &p.Username }},
+		{name: "file or directory", text: func(p *Preferences) *string { return &p.Leaderboard }},
+	}, localOnly: true},
+}
+
+func (i SettingItem) Display(p *Preferences) string {
+	switch {
+	case i.toggle != nil:
+		if *i.toggle(p) {
+			return "[X]"
+		}
+		return "[ ]"
+	case i.text != nil:
+		return fmt.Sprintf("< %s >", *i.text(p))
+	case i.choice != nil:
+		return fmt.Sprintf("< %s >", *i.choice(p))
+	case i.choices != nil:
+		return fmt.Sprintf("< %s >", i.choices[*i.value(p)])
+	default:
+		return fmt.Sprintf("< %d >", *i.value(p))
+	}
+}
+
+// Change moves the item's value by delta, wrapping around for toggles and
+// enumerations and clamping numbers to their range.
+func (i SettingItem) Change(p *Preferences, delta int) {
+	switch {
+	case i.toggle != nil:
+		*i.toggle(p) = !*i.toggle(p)
+	case i.text != nil:
+	case i.choice != nil:
+		c := i.choice(p)
+		v := max(slices.Index(i.choices, *c), 0)
+		*c = i.choices[(v+delta+len(i.choices))%len(i.choices)]
+	case i.choices != nil:
+		v := i.value(p)
+		*v = (*v + delta + len(i.choices)) % len(i.choices)
+	default:
+		i.Set(p, *i.value(p)+delta)
+	}
+}
+
+// Clamp brings a value read from the settings file back within the item's
+// range, or back to its default when it isn't one of the choices. Layouts are
+// left alone as a missing one falls back to QWERTY, see currentLayout.
+func (i SettingItem) Clamp(p *Preferences) {
+	switch {
+	case i.toggle != nil, i.text != nil, i.choice != nil:
+	case i.choices != nil:
+		if v := *i.value(p); v < 0 || v >= len(i.choices) {
+			defaults := DefaultPreferences()
+			*i.value(p) = *i.value(&defaults)
+		}
+	default:
+		i.Set(p, *i.value(p))
+	}
+}
+
+func (i SettingItem) Set(p *Preferences, v int) {
+	if v < i.min {
+		v = i.min
+	}
+	if v > i.max {
+		v = i.max
+	}
+	*i.value(p) = v
+}
+
+var _ Drawable = (*Settings)(nil)
+
+type Settings struct {
+	screen tcell.Screen
+	prefs  *Preferences
+	groups []SettingGroup
+
+	curr  int
+	input string
+}
+
+func NewSettings(screen tcell.Screen) Drawable {
+	var groups []SettingGroup
+	for _, group := range SettingGroups {
+		if !group.localOnly || !sessionOf(screen).remote {
+			groups = append(groups, group)
+		}
+	}
+
+	return &Settings{
+		screen: screen,
+		prefs:  prefsOf(screen),
+		groups: groups,
+	}
+}
+
+func (s *Settings) Init() {
+}
+
+func (s *Settings) Draw() {
+	sWidth, sHeight := s.screen.Size()
+	boxWidth := sWidth / 2
+	startW := (sWidth - boxWidth) / 2
+
+	title := "settings"
+	drawTextCentered(s.screen, len(title), 1, title, AppYellowTextStyle)
+
+	// scroll the list so the selected item stays on screen
+	startH := 3
+	selectedRow := s.rowOf(s.curr)
+	if maxRow := sHeight - 3 - startH; selectedRow > maxRow {
+		startH -= selectedRow - maxRow
+	}
+
+	row := 0
+	idx := 0
+	for _, group := range s.groups {
+		if row > 0 {
+			row++
+		}
+		s.drawLine(startW, startH+row, group.name, AppYellowTextStyle)
+		row++
+
+		for _, item := range group.items {
+			value := item.Display(s.prefs)
+			style := AppTextStyle
+			name := "  " + item.name
+			if idx == s.curr {
+				style = AppYellowTextStyle
+				name = fmt.Sprintf("%c %s", tcell.RuneDiamond, item.name)
+				if s.input != "" {
+					value = fmt.Sprintf("< %s_ >", s.input)
+				} else if item.text != nil {
+					value = fmt.Sprintf("< %s_ >", *item.text(s.prefs))
+				}
+			}
+			s.drawLine(startW+2, startH+row, name, style)
+			s.drawLine(startW+boxWidth-len(value), startH+row, value, style)
+			row++
+			idx++
+		}
+	}
+
+	help := "up/down to move, left/right to change, enter to toggle, o to go back..."
+	if s.TakesText() {
+		help = "up/down to move, type to edit, backspace to delete..."
+	}
+	drawTextCentered(s.screen, len(help), sHeight-2, help, AppTextStyle)
+}
+
+// drawLine draws a line of the list unless it has been scrolled above it.
+func (s *Settings) drawLine(w, h int, text string, style tcell.Style) {
+	if h < 3 {
+		return
+	}
+	drawText(s.screen, len(text), w, h, text, style)
+}
+
+// rowOf returns the row, relative to the top of the list, of the n-th item.
+func (s *Settings) rowOf(n int) int {
+	row := 0
+	for g, group := range s.groups {
+		if g > 0 {
+			row++
+		}
+		row++
+		if n < len(group.items) {
+			return row + n
+		}
+		n -= len(group.items)
+		row += len(group.items)
+	}
+	return row
+}
+
+func (s *Settings) item(n int) SettingItem {
+	for _, group := range s.groups {
+		if n < len(group.items) {
+			return group.items[n]
+		}
+		n -= len(group.items)
+	}
+	return SettingItem{}
+}
+
+func (s *Settings) count() int {
+	n := 0
+	for _, group := range s.groups {
+		n += len(group.items)
+	}
+	return n
+}
+
+// TakesText reports whether a text item is selected, which every key is
+// typed into.
+func (s *Settings) TakesText() bool {
+	return s.item(s.curr).text != nil
+}
+
+func (s *Settings) Update(event tcell.Event) Drawable {
+	k := event.(*tcell.EventKey)
+	item := s.item(s.curr)
+	if item.text != nil {
+		s.updateText(k, item.text(s.prefs))
+	}
+
+	switch k.Key() {
+	case tcell.KeyUp:
+		s.input = ""
+		if s.curr > 0 {
+			s.curr -= 1
+		} else {
+			s.curr = s.count() - 1
+		}
+	case tcell.KeyDown:
+		s.input = ""
+		if s.curr < s.count()-1 {
+			s.curr += 1
+		} else {
+			s.curr = 0
+		}
+	case tcell.KeyLeft:
+		s.input = ""
+		item.Change(s.prefs, -1)
+	case tcell.KeyRight:
+		s.input = ""
+		item.Change(s.prefs, 1)
+	case tcell.KeyEnter:
+		if s.input != "" {
+			v, _ := strconv.Atoi(s.input)
+			item.Set(s.prefs, v)
+			s.input = ""
+		} else if item.toggle != nil {
+			item.Change(s.prefs, 1)
+		}
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(s.input) > 0 {
+			s.input = s.input[:len(s.input)-1]
+		}
+	case tcell.KeyRune:
+		if k.Rune() == 'o' && item.text == nil {
+			return NewMenu(s.screen)
+		}
+		// numbers can also be typed in directly and applied with enter
+		if unicode.IsDigit(k.Rune()) && item.toggle == nil && item.text == nil && item.choices == nil && len(s.input) < 4 {
+			s.input += string(k.Rune())
+		}
+	}
+
+	_ = s.prefs.Save(storeOf(s.screen))
+	return nil
+}
+
+// updateText edits the text of the selected item as it's typed.
+func (s *Settings) updateText(k *tcell.EventKey, text *string) {
+	switch k.Key() {
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(*text) > 0 {
+			runes := []rune(*text)
+			*text = string(runes[:len(runes)-1])
+		}
+	case tcell.KeyRune:
+		if unicode.IsPrint(k.Rune()) && len(*text) < MAX_SETTING_TEXT {
+			*text += string(k.Rune())
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// codes start with the version of their format
+const SHARE_PREFIX = "mt1"
+
+var ErrInvalidCode = errors.New("invalid test code")
+
+// ShareCode holds everything needed to take the exact same test elsewhere.
+// The text is included so tests built from local state, like lessons and
+// practice, come out the same too.
+type ShareCode struct {
+	Kind   int    `json:"k"`
+	Config Config `json:"c"`
+	Text   string `json:"t"`
+	Seed   int64  `json:"s"`
+}
+
+// Encode returns the code as deflated JSON in URL safe base64, which
+// survives being pasted in chat.
+func (c ShareCode) Encode() (string, error) {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(content); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return SHARE_PREFIX + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeShareCode reads a code made by Encode, ignoring any whitespace a
+// terminal or chat added when it was copied.
+func DecodeShareCode(code string) (ShareCode, error) {
+	var c ShareCode
+
+	code = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, code)
+	if !strings.HasPrefix(code, SHARE_PREFIX) {
+		return c, ErrInvalidCode
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(code, SHARE_PREFIX))
+	if err != nil {
+		return c, ErrInvalidCode
+	}
+	content, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		return c, ErrInvalidCode
+	}
+	if err := json.Unmarshal(content, &c); err != nil || !c.valid() {
+		return c, ErrInvalidCode
+	}
+
+	return c, nil
+}
+
+func (c ShareCode) valid() bool {
+	return validText(c.Text) &&
+		knownKind(c.Kind) &&
+		(c.Kind != TEST_TIME || c.Config.Duration > 0) &&
+		(c.Kind == TEST_TIME || c.Kind == TEST_QUOTE || c.Config.Words > 0) &&
+		c.Config.QuoteLen >= 0 && c.Config.QuoteLen < len(QuoteTypes) &&
+		c.Config.Lesson >= 0 && c.Config.Lesson < len(Curriculum) &&
+		c.Config.Drill >= 0 && c.Config.Drill < len(Drills) &&
+		validBots(c.Config.Bots)
+}
+
+// validText reports whether a text is made of printable ASCII words separated
+// by single spaces, the only texts a test can lay out.
+func validText(text string) bool {
+	for _, word := range strings.Split(text, " ") {
+		if word == "" {
+			return false
+		}
+		for i := 0; i < len(word); i++ {
+			if word[i] <= ' ' || word[i] > '~' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validBots(bots []int) bool {
+	for _, b := range bots {
+		if b < 0 || b >= len(BotProfiles) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewSharedTest starts the test a code describes.
+func NewSharedTest(screen tcell.Screen, c ShareCode) Drawable {
+	return restartTest(screen, c.Kind, c.Config, c.Seed, c.Text, true)
+}
+
+// drawCode draws a code over as many lines as it needs, it has no spaces to
+// wrap it at.
+func drawCode(screen tcell.Screen, code string, startH, width int) int {
+	startW := centerWidth(screen, width)
+	for i := 0; i < len(code); i += width {
+		end := i + width
+		if end > len(code) {
+			end = len(code)
+		}
+		drawText(screen, width, startW, startH, code[i:end], AppYellowTextStyle)
+		startH++
+	}
+	return startH
+}
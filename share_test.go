@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestShareCodeRoundTrip(t *testing.T) {
+	for _, c := range []ShareCode{
+		{Kind: TEST_WORD, Config: Config{Words: 25, Punctuation: true}, Text: "the quick brown fox", Seed: 42},
+		{Kind: TEST_TIME, Config: Config{Duration: 15, Words: 22}, Text: "jumps over the lazy dog", Seed: -7},
+		{Kind: TEST_QUOTE, Config: Config{QuoteLen: 2}, Text: "Hello, world! (it's 1 quote)"},
+		{Kind: TEST_RACE, Config: Config{Words: 10, Bots: []int{0, 3}}, Text: "race me"},
+		{Kind: TEST_DRILL, Config: Config{Words: LESSON_WORDS, Drill: len(Drills) - 1}, Text: "asdf jkl"},
+		{Kind: TEST_CURRICULUM, Config: Config{Words: LESSON_WORDS, Lesson: len(Curriculum) - 1}, Text: "fff jjj"},
+	} {
+		code, err := c.Encode()
+		if err != nil {
+			t.Fatalf("encoding %+v: %v", c, err)
+		}
+		// terminals and chats break long codes over lines
+		decoded, err := DecodeShareCode(code[:10] + "\n  " + code[10:])
+		if err != nil {
+			t.Fatalf("decoding %+v: %v", c, err)
+		}
+		if !reflect.DeepEqual(decoded, c) {
+			t.Errorf("got %+v, want %+v", decoded, c)
+		}
+	}
+}
+
+func TestShareCodeRejects(t *testing.T) {
+	valid := ShareCode{Kind: TEST_WORD, Config: Config{Words: 2}, Text: "two words"}
+	encode := func(change func(c *ShareCode)) string {
+		c := valid
+		change(&c)
+		code, err := c.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	code := encode(func(c *ShareCode) {})
+
+	for _, tt := range []struct {
+		name string
+		code string
+	}{
+		{"empty", ""},
+		{"bad prefix", "mt0" + code[len(SHARE_PREFIX):]},
+		{"no prefix", code[len(SHARE_PREFIX):]},
+		{"bad base64", SHARE_PREFIX + "!!!!"},
+		{"not deflated", SHARE_PREFIX + "aGVsbG8"},
+		{"truncated", code[:len(code)-4]},
+		{"unknown kind", encode(func(c *ShareCode) { c.Kind = TEST_TEXT + 1 })},
+		{"negative kind", encode(func(c *ShareCode) { c.Kind = -1 })},
+		{"bot too high", encode(func(c *ShareCode) { c.Kind, c.Config.Bots = TEST_RACE, []int{len(BotProfiles)} })},
+		{"negative bot", encode(func(c *ShareCode) { c.Kind, c.Config.Bots = TEST_RACE, []int{-1} })},
+		{"lesson too high", encode(func(c *ShareCode) { c.Kind, c.Config.Lesson = TEST_CURRICULUM, len(Curriculum) })},
+		{"negative lesson", encode(func(c *ShareCode) { c.Config.Lesson = -1 })},
+		{"drill too high", encode(func(c *ShareCode) { c.Kind, c.Config.Drill = TEST_DRILL, len(Drills) })},
+		{"negative drill", encode(func(c *ShareCode) { c.Config.Drill = -1 })},
+		{"quote too long", encode(func(c *ShareCode) { c.Kind, c.Config.QuoteLen = TEST_QUOTE, len(QuoteTypes) })},
+		{"no duration", encode(func(c *ShareCode) { c.Kind, c.Config.Duration = TEST_TIME, 0 })},
+		{"negative duration", encode(func(c *ShareCode) { c.Kind, c.Config.Duration = TEST_TIME, -15 })},
+		{"no words", encode(func(c *ShareCode) { c.Config.Words = 0 })},
+		{"no text", encode(func(c *ShareCode) { c.Text = "" })},
+		{"double space", encode(func(c *ShareCode) { c.Text = "two  words" })},
+		{"trailing space", encode(func(c *ShareCode) { c.Text = "two words " })},
+		{"newline", encode(func(c *ShareCode) { c.Text = "two\nwords" })},
+		{"tab", encode(func(c *ShareCode) { c.Text = "two\twords" })},
+		{"non ascii", encode(func(c *ShareCode) { c.Text = "two wörds" })},
+		{"escape", encode(func(c *ShareCode) { c.Text = "two \x1b[2Jwords" })},
+	} {
+		if _, err := DecodeShareCode(tt.code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, ErrInvalidCode)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	SSH_ADDR     = "127.0.0.1:2222"
+	SSH_HOST_KEY = "ssh_host_ed25519_key"
+	// every SSH user gets a store in here, named after their public key
+	SSH_USERS_DIR = "users"
+	// terminals that have no terminfo entry are treated as this one
+	SSH_FALLBACK_TERM = "xterm-256color"
+	// the name a key's scores are posted under, the first one it logged in as
+	USERNAME_FILE = "username"
+	// the most characters of a login name that are kept, which fits the
+	// leaderboard's user column along with the fingerprint
+	MAX_USERNAME = 16
+)
+
+var errDrained = errors.New("tty drained")
+
+// serveSSHCommand runs `monkeytype serve-ssh`, serving the app until it's
+// killed to anyone connecting with an authorized key, or any key when no
+// authorized keys are given.
+func serveSSHCommand(args []string) error {
+	flags := flag.NewFlagSet("serve-ssh", flag.ExitOnError)
+	addr := flags.String("listen", SSH_ADDR, "address to accept ssh connections on")
+	hostKey := flags.String("host-key", "", "host key file, generated on first run by default")
+	authorizedKeys := flags.String("authorized-keys", "", "only accept the keys in this authorized_keys file")
+	_ = flags.Parse(args)
+
+	allowed := func(key ssh.PublicKey) bool {
+		return true
+	}
+	if *authorizedKeys != "" {
+		keys, err := loadAuthorizedKeys(*authorizedKeys)
+		if err != nil {
+			return err
+		}
+		allowed = func(key ssh.PublicKey) bool {
+			for _, k := range keys {
+				if ssh.KeysEqual(k, key) {
+					return true
+				}
+			}
+			return false
+		}
+	} else {
+		log.Printf("accepting any key, pass --authorized-keys to restrict who can connect")
+	}
+
+	if *hostKey == "" {
+		path, err := LocalStore.path(SSH_HOST_KEY)
+		if err != nil {
+			return err
+		}
+		*hostKey = path
+	}
+	if err := ensureHostKey(*hostKey); err != nil {
+		return err
+	}
+
+	server := &ssh.Server{
+		Addr:    *addr,
+		Handler: handleSSH,
+		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool {
+			return allowed(key)
+		},
+	}
+	if err := server.SetOption(ssh.HostKeyFile(*hostKey)); err != nil {
+		return err
+	}
+
+	log.Printf("serving over ssh on %s", *addr)
+	return server.ListenAndServe()
+}
+
+// loadAuthorizedKeys reads the keys of a file in the format of OpenSSH's
+// authorized_keys.
+func loadAuthorizedKeys(path string) ([]ssh.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []ssh.PublicKey
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, _, _, _, err := gossh.ParseAuthorizedKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ensureHostKey generates an ed25519 host key unless there already is one.
+func ensureHostKey(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	block, err := gossh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return err
+	}
+
+	log.Printf("generated a host key in %s", path)
+	return os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
+}
+
+// handleSSH runs the app over a session, on a screen of its own.
+func handleSSH(s ssh.Session) {
+	pty, windows, ok := s.Pty()
+	if !ok {
+		_, _ = io.WriteString(s, "monkeytype needs a terminal, connect with ssh -t\n")
+		_ = s.Exit(1)
+		return
+	}
+
+	store, err := userStore(s.PublicKey())
+	if err != nil {
+		log.Printf("no store for %s: %v", s.User(), err)
+		_ = s.Exit(1)
+		return
+	}
+	fingerprint := gossh.FingerprintSHA256(s.PublicKey())
+	name, err := claimUsername(store, s.User(), fingerprint)
+	if err != nil {
+		log.Printf("no username for %s: %v", s.User(), err)
+		_ = s.Exit(1)
+		return
+	}
+
+	tty := newSSHTty(s, pty.Window, windows)
+	defer tty.Close()
+
+	ti, err := tcell.LookupTerminfo(pty.Term)
+	if err != nil {
+		ti, err = tcell.LookupTerminfo(SSH_FALLBACK_TERM)
+	}
+	if err != nil {
+		log.Printf("no terminfo for %s: %v", s.User(), err)
+		_ = s.Exit(1)
+		return
+	}
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, ti)
+	if err == nil {
+		err = screen.Init()
+	}
+	if err != nil {
+		log.Printf("no screen for %s: %v", s.User(), err)
+		_ = s.Exit(1)
+		return
+	}
+	defer screen.Fini()
+
+	// a crash only ends the session it happened in
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("session of %s crashed: %v", s.User(), p)
+		}
+	}()
+
+	log.Printf("%s connected from %s", s.User(), s.RemoteAddr())
+	session := NewSession(screen, store, true)
+	session.user = name
+	session.key = fingerprint
+	run(session, NewMenu(session))
+	log.Printf("%s disconnected", s.User())
+
+	_ = s.Exit(0)
+}
+
+// userStore returns the store of the user a public key belongs to.
+func userStore(key ssh.PublicKey) (Store, error) {
+	dir, err := LocalStore.path(SSH_USERS_DIR)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(key.Marshal())
+	return Store(filepath.Join(dir, hex.EncodeToString(sum[:]))), nil
+}
+
+// claimUsername returns the name saved in a store, saving name there when
+// there's none yet. Logging in under another name later doesn't change it.
+// Names are cleaned up first, falling back to the key's fingerprint when
+// nothing is left of them.
+func claimUsername(store Store, name, fingerprint string) (string, error) {
+	path, err := store.path(USERNAME_FILE)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if saved := cleanUsername(string(content)); err == nil && saved != "" {
+		return saved, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	name = cleanUsername(name)
+	if name == "" {
+		name = cleanUsername(strings.TrimPrefix(fingerprint, "SHA256:"))
+	}
+	return name, os.WriteFile(path, []byte(name), 0o644)
+}
+
+// cleanUsername keeps the printable ASCII characters of a login name, as
+// it's drawn on everyone's leaderboard, and cuts it to MAX_USERNAME.
+func cleanUsername(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if len(name) > MAX_USERNAME {
+		name = strings.TrimSpace(name[:MAX_USERNAME])
+	}
+	return name
+}
+
+var _ tcell.Tty = (*sshTty)(nil)
+
+// sshTty lets tcell draw on an SSH session as it would on a terminal.
+type sshTty struct {
+	session ssh.Session
+	input   chan []byte
+	pending []byte
+	done    chan struct{}
+	close   sync.Once
+
+	mu      sync.Mutex
+	size    ssh.Window
+	resize  func()
+	drained chan struct{}
+}
+
+func newSSHTty(session ssh.Session, size ssh.Window, windows <-chan ssh.Window) *sshTty {
+	t := &sshTty{
+		session: session,
+		input:   make(chan []byte),
+		done:    make(chan struct{}),
+		size:    size,
+		drained: make(chan struct{}),
+	}
+	go t.readInput()
+	go t.watchSize(windows)
+	return t
+}
+
+// readInput reads the session in the background, so reads can be
+// interrupted by Drain.
+func (t *sshTty) readInput() {
+	defer close(t.input)
+	for {
+		buf := make([]byte, 128)
+		n, err := t.session.Read(buf)
+		if n > 0 {
+			select {
+			case t.input <- buf[:n]:
+			case <-t.done:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *sshTty) watchSize(windows <-chan ssh.Window) {
+	for {
+		select {
+		case size, ok := <-windows:
+			if !ok {
+				return
+			}
+			t.mu.Lock()
+			t.size = size
+			resize := t.resize
+			t.mu.Unlock()
+			if resize != nil {
+				resize()
+			}
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *sshTty) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.drained:
+		t.drained = make(chan struct{})
+	default:
+	}
+	return nil
+}
+
+func (t *sshTty) Stop() error {
+	return nil
+}
+
+func (t *sshTty) Drain() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.drained:
+	default:
+		close(t.drained)
+	}
+	return nil
+}
+
+func (t *sshTty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resize = cb
+}
+
+func (t *sshTty) WindowSize() (tcell.WindowSize, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tcell.WindowSize{Width: t.size.Width, Height: t.size.Height}, nil
+}
+
+func (t *sshTty) Read(p []byte) (int, error) {
+	if len(t.pending) == 0 {
+		t.mu.Lock()
+		drained := t.drained
+		t.mu.Unlock()
+
+		select {
+		case in, ok := <-t.input:
+			if !ok {
+				return 0, io.EOF
+			}
+			t.pending = in
+		case <-drained:
+			return 0, errDrained
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *sshTty) Write(p []byte) (int, error) {
+	return t.session.Write(p)
+}
+
+func (t *sshTty) Close() error {
+	t.close.Do(func() {
+		close(t.done)
+	})
+	return nil
+}
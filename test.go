@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"fmt"
 	"math/rand"
+	"runtime"
 	"strings"
 	"time"
 
@@ -22,12 +23,26 @@ var mediumQuotes string
 //go:embed res/short_quotes.txt
 var shortQuotes string
 
+// Test kinds are saved in the history, share codes and the api, so their
+// values never change. New kinds get the next free value.
 const (
-	TEST_WORD int = iota
-	TEST_TIME
-	TEST_QUOTE
+	TEST_WORD       int = 0
+	TEST_TIME       int = 1
+	TEST_QUOTE      int = 2
+	TEST_PRACTICE   int = 3
+	TEST_LESSON     int = 4
+	TEST_DRILL      int = 5
+	TEST_RACE       int = 6
+	TEST_CURRICULUM int = 7
+	// a test over a given text, like practice of missed words
+	TEST_TEXT int = 8
 )
 
+// knownKind reports whether a saved or shared kind is one this version has.
+func knownKind(kind int) bool {
+	return kind >= TEST_WORD && kind <= TEST_TEXT
+}
+
 const (
 	MAX_NUM             = 100000
 	NUM_FACTOR          = 8
@@ -39,11 +54,18 @@ const (
 var QuoteTypes = []string{"short", "medium", "long"}
 
 type Config struct {
-	Punctuation bool
-	Number      bool
-	Words       int
-	Duration    int
-	QuoteLen    int
+	Punctuation bool  `json:"punctuation"`
+	Number      bool  `json:"number"`
+	Words       int   `json:"words"`
+	Duration    int   `json:"duration"`
+	QuoteLen    int   `json:"quote_len"`
+	Blind       bool  `json:"blind"`
+	Lesson      int   `json:"lesson,omitempty"`
+	Drill       int   `json:"drill,omitempty"`
+	Bots        []int `json:"bots,omitempty"`
+	// a fixed seed makes the test generate the same text every time, a
+	// random one is picked when it's zero
+	Seed int64 `json:"seed,omitempty"`
 }
 
 var _ Drawable = (*Test)(nil)
@@ -54,11 +76,31 @@ type Test struct {
 	kind   int
 	config Config
 
-	startTime  time.Time
-	txt        string
+	clock Clock
+	seed  int64
+	txt   string
+	// the text was given rather than generated, restarting keeps it
+	givenText bool
+	// the words of practice tests, see practiceText
+	practice   []string
 	typedTxt   string
 	words      int
 	typedWords int
+
+	keystrokes int
+	mistakes   int
+
+	ticker    *time.Ticker
+	done      chan struct{}
+	samples   []Sample
+	wordStart time.Duration
+	lastBurst float64
+	bursts    map[int]float64
+
+	keyStats  KeyStats
+	lastKeyAt time.Duration
+
+	race Race
 }
 
 func NewTest(screen tcell.Screen, kind int, config Config) Drawable {
@@ -66,124 +108,223 @@ func NewTest(screen tcell.Screen, kind int, config Config) Drawable {
 		screen: screen,
 		kind:   kind,
 		config: config,
+		bursts: map[int]float64{},
+
+		keyStats: KeyStats{},
+	}
+}
+
+// NewPracticeTest creates a test over the given words, shuffled by the
+// test's seed. Restarting it keeps the text.
+func NewPracticeTest(screen tcell.Screen, words []string) Drawable {
+	t := NewTest(screen, TEST_TEXT, Config{}).(*Test)
+	t.givenText = true
+	t.practice = words
+	return t
+}
+
+// restartTest starts a test over. Tests of a given text, like imported or
+// practice ones, keep their text and seed while the others generate new ones.
+func restartTest(screen tcell.Screen, kind int, config Config, seed int64, text string, given bool) Drawable {
+	if !given {
+		return NewTest(screen, kind, config)
+	}
+	return &Test{
+		screen:    screen,
+		kind:      kind,
+		config:    config,
+		seed:      seed,
+		txt:       text,
+		givenText: true,
+		bursts:    map[int]float64{},
+
+		keyStats: KeyStats{},
 	}
 }
 
+func (t *Test) prefs() *Preferences {
+	return prefsOf(t.screen)
+}
+
+func (t *Test) restart() Drawable {
+	return restartTest(t.screen, t.kind, t.config, t.seed, t.txt, t.givenText)
+}
+
 func (t *Test) Init() {
 	t.generateText()
+	t.startTicker()
 
-	if t.kind == TEST_TIME {
-		ti := time.NewTicker(time.Second)
-		go func() {
-			for {
-				<-ti.C
-				_ = t.screen.PostEvent(tcell.NewEventMouse(0, 0, tcell.RuneS1, tcell.ModAlt))
-			}
-		}()
+	if t.kind == TEST_RACE {
+		r := rand.New(rand.NewSource(t.seed))
+		t.race = NewBotRace(r, t.config.Bots, t.txt, &t.clock)
+	}
+	if t.race != nil {
+		t.race.Watch(t)
+	}
+	// LAN races start for everyone at once, not on the first key
+	if t.lanRace() {
+		t.clock.Start()
 	}
 }
 
+func (t *Test) lanRace() bool {
+	_, ok := t.race.(*NetRace)
+	return ok
+}
+
 func (t *Test) Draw() {
 	swidth, _ := t.screen.Size()
-	lineLen := swidth / 2
+	lineLen := swidth * t.prefs().TextWidth / 100
 
 	startW := centerWidth(t.screen, lineLen)
 	startH := 4
+	if t.race != nil {
+		startH = drawLanes(t.screen, t.race.Lanes(), startW, startH-2, lineLen) + 2
+	}
 	t.drawCounter(startW-2, startH-1)
 
-	var x int
-	drawFn := func(ch byte, style tcell.Style) {
-		t.screen.SetContent(startW+(x%lineLen), startH+(x/lineLen), rune(ch), nil, style)
-		x++
+	if t.clock.IsPaused() {
+		t.drawPaused(startH)
+		return
 	}
 
-	targetWords := strings.Fields(t.txt)
-	typedWords := strings.Fields(t.typedTxt)
-	for i := 0; i < len(targetWords); i++ {
-		targetWord := targetWords[i]
-		typedWord := ""
-		if i < len(typedWords) {
-			typedWord = typedWords[i]
-		}
-
-		for j := 0; j < len(targetWord); j++ {
-			if j < len(typedWord) {
-				if targetWord[j] == typedWord[j] {
-					drawFn(targetWord[j], CorrectTextStyle)
-				} else {
-					drawFn(targetWord[j], WrongTextStyle)
-				}
-			} else {
-				drawFn(targetWord[j], TargetTextStyle)
-			}
-		}
-
-		if len(typedWord) > len(targetWord) {
-			extraChars := typedWord[len(targetWord):]
-			for _, ch := range extraChars {
-				if ch == ' ' || string(ch) == WRONG_CHAR {
-					break
-				}
-				drawFn(byte(ch), WrongTextStyle)
-			}
-		}
-
-		if i != len(targetWords)-1 {
-			drawFn(' ', AppTextStyle)
-		}
+	var caretX, caretY int
+	if t.prefs().TapeMode {
+		layout := layoutTape(t.txt, t.typedTxt, t.config.Blind, t.prefs().HideExtra)
+		caretX, caretY = layout.drawTape(t.screen, startW, startH+1, lineLen)
+	} else {
+		layout := layoutText(t.txt, t.typedTxt, lineLen, t.config.Blind, t.prefs().HideExtra)
+		caretX, caretY = layout.draw(t.screen, startW, startH, VISIBLE_LINES)
 	}
+	drawCaret(t.screen, caretX, caretY)
 
-	for i := len(targetWords); i < len(typedWords); i++ {
-		for _, ch := range typedWords[i] {
-			drawFn(byte(ch), WrongTextStyle)
-		}
-
-		if i != len(typedWords)-1 {
-			drawFn(' ', AppTextStyle)
-		}
+	_, sHeight := t.screen.Size()
+	help := "ctrl+w or ctrl+backspace to delete a word"
+	if !t.lanRace() {
+		help += ", ctrl+p to pause"
+	}
+	if t.prefs().QuickRestart && !t.lanRace() {
+		help = "tab to restart, " + help
 	}
+	help += "..."
+	drawTextCentered(t.screen, len(help), sHeight-2, help, TargetTextStyle)
 }
 
 func (t *Test) drawCounter(w, h int) {
 	counter := fmt.Sprintf("%d/%d", t.typedWords+1, t.words)
 	if t.kind == TEST_TIME {
-		dt := time.Time{}
-		td := t.config.Duration - int(time.Since(t.startTime).Seconds())
-		if t.startTime == dt {
-			td = t.config.Duration
-		}
+		td := t.config.Duration - int(t.clock.Elapsed().Seconds())
 		counter = fmt.Sprintf("%d", td)
 	}
+	if t.prefs().LiveWpm && t.clock.Started() {
+		counter += fmt.Sprintf("  %d wpm", t.liveWpm())
+	}
 
 	drawText(t.screen, len(counter), w, h, counter, AppYellowTextStyle)
 }
 
+// drawPaused hides the text behind a notice while the test is paused.
+func (t *Test) drawPaused(startH int) {
+	txt := "paused, press ctrl+p to resume..."
+	boxWidth := len(txt) + 4
+	startW, _ := drawCenteredBox(t.screen, startH, boxWidth, VISIBLE_LINES+1, BoxStyle, AppYellowTextStyle)
+	drawText(t.screen, len(txt), startW+2, startH+2, txt, BoxStyle)
+}
+
+func (t *Test) liveWpm() int {
+	return int(float64(t.correctChars()) / 5 / t.clock.Elapsed().Minutes())
+}
+
+func (t *Test) TakesText() bool {
+	return true
+}
+
 func (t *Test) Update(event tcell.Event) Drawable {
-	key := event.(*tcell.EventKey)
+	var next Drawable
+	switch ev := event.(type) {
+	case *EventTick:
+		next = t.tick()
+	case *tcell.EventKey:
+		next = t.handleKey(translateKey(ev, t.prefs()))
+	case *EventRace:
+		// only redraws the opponents
+	}
+
+	if next != nil {
+		t.stopTicker()
+	}
+	return next
+}
+
+func (t *Test) handleKey(key *tcell.EventKey) Drawable {
+	if key.Key() == tcell.KeyTab && t.prefs().QuickRestart && !t.lanRace() {
+		return t.restart()
+	}
+
+	if key.Key() == tcell.KeyCtrlP && !t.lanRace() {
+		if t.clock.IsPaused() {
+			t.clock.Resume()
+		} else {
+			t.clock.Pause()
+		}
+		return nil
+	}
+	if t.clock.IsPaused() {
+		return nil
+	}
+
 	if key.Key() == tcell.KeyRune {
-		if key.Rune() == ' ' {
-			for len(t.typedTxt) < len(t.txt) && t.txt[len(t.typedTxt)] != ' ' {
-				t.typedTxt += WRONG_CHAR
-			}
-			t.typedWords += 1
+		t.clock.Start()
+
+		correct := t.keyCorrect(key.Rune())
+		t.recordKey(correct)
+		t.keystrokes += 1
+		if !correct {
+			t.mistakes += 1
+		}
+		t.beep(correct)
+		if !correct && t.prefs().Difficulty == DIFFICULTY_MASTER {
+			return t.fail("incorrect key")
 		}
-		dt := time.Time{}
-		if t.startTime == dt {
-			t.startTime = time.Now()
+
+		if t.accepts(key.Rune(), correct) {
+			target, typed := t.currentWord()
+			if key.Rune() == ' ' {
+				for i := len(typed); i < len(target); i++ {
+					t.typedTxt += WRONG_CHAR
+				}
+				t.typedWords += 1
+				t.lastBurst = t.wordBurst(len(typed) + 1)
+				t.bursts[strings.Count(t.typedTxt, " ")] = t.lastBurst
+			} else if typed == "" {
+				t.wordStart = t.clock.Elapsed()
+			}
+			t.typedTxt += string(key.Rune())
+
+			if key.Rune() == ' ' && t.prefs().Difficulty == DIFFICULTY_EXPERT && !t.previousWordCorrect() {
+				return t.fail("incorrect word")
+			}
+			if key.Rune() == ' ' {
+				if next := t.checkBurst(); next != nil {
+					return next
+				}
+			}
 		}
-		t.typedTxt += string(key.Rune())
 	}
 
 	switch key.Key() {
-	case tcell.KeyBackspace, tcell.KeyBackspace2:
-		if len(t.typedTxt) != 0 {
-			if t.typedTxt[len(t.typedTxt)-1] == ' ' {
-				t.typedWords -= 1
-			}
-			t.typedTxt = t.typedTxt[:len(t.typedTxt)-1]
+	case tcell.KeyBackspace, tcell.KeyBackspace2, tcell.KeyCtrlW:
+		if t.deletesWord(key) {
+			t.deleteWord()
+		} else {
+			t.deleteChar()
 		}
 	}
 
+	if t.race != nil && t.clock.Started() {
+		t.race.Report(float64(t.correctChars())/float64(len(t.txt)), t.liveWpm())
+	}
+
 	if next := t.finish(); next != nil {
 		return next
 	}
@@ -192,49 +333,274 @@ func (t *Test) Update(event tcell.Event) Drawable {
 }
 
 func (t *Test) finish() Drawable {
+	testDuration := time.Second * time.Duration(t.config.Duration)
 	if t.txt == t.typedTxt || t.words == t.typedWords ||
-		(t.kind == TEST_TIME && time.Now().After(t.startTime.Add(time.Second*time.Duration(t.config.Duration)))) {
-		duration := time.Since(t.startTime)
+		(t.kind == TEST_TIME && t.clock.Elapsed() >= testDuration) {
+		metric := t.metric()
 		if t.kind == TEST_TIME {
-			duration = time.Duration(t.config.Duration) * time.Second
+			metric.duration = testDuration + t.clock.Paused()
 		}
 
-		correctWords := strings.Split(t.txt, " ")
-		typedWords := strings.Split(t.typedTxt, " ")
+		return NewResult(t.screen, metric)
+	}
 
-		correct := 0
-		for i := 0; i < len(correctWords); i++ {
-			for j := 0; j < len(correctWords[i]); j++ {
-				if correctWords[i][j] == typedWords[i][j] {
-					correct += 1
-				}
-			}
+	return nil
+}
+
+// fail ends the test early without scoring it.
+func (t *Test) fail(reason string) Drawable {
+	metric := t.metric()
+	metric.failed = true
+	metric.failReason = reason
+
+	return NewResult(t.screen, metric)
+}
+
+func (t *Test) metric() Metric {
+	return Metric{
+		kind:         t.kind,
+		config:       t.config,
+		seed:         t.seed,
+		duration:     t.clock.Wall(),
+		paused:       t.clock.Paused(),
+		allChars:     len(t.typedTxt),
+		correctChars: t.correctChars(),
+		text:         t.txt,
+		givenText:    t.givenText,
+		typed:        t.typedTxt,
+		words:        t.alignWords(),
+		keystrokes:   t.keystrokes,
+		mistakes:     t.mistakes,
+		keyStats:     t.keyStats,
+		samples:      t.samples,
+		difficulty:   t.prefs().Difficulty,
+		stopOnError:  t.prefs().StopOnError,
+		strictSpace:  t.prefs().StrictSpace,
+		race:         t.race,
+	}
+}
+
+// alignWords pairs every finished word with the word of the text it was
+// meant to be. The word being typed only counts once it's complete.
+func (t *Test) alignWords() []WordResult {
+	correctWords := strings.Split(t.txt, " ")
+	typedWords := strings.Split(t.typedTxt, " ")
+
+	var results []WordResult
+	for i, typed := range typedWords {
+		expected := ""
+		if i < len(correctWords) {
+			expected = correctWords[i]
+		}
+		if i == len(typedWords)-1 && typed != expected {
+			break
 		}
 
-		return NewResult(t.screen, Metric{
-			duration:     duration,
-			allChars:     len(t.txt),
-			correctChars: correct + len(correctWords) - 1,
+		results = append(results, WordResult{
+			expected: expected,
+			typed:    strings.TrimRight(typed, WRONG_CHAR),
+			correct:  typed == expected,
+			burst:    t.bursts[i],
 		})
 	}
 
-	return nil
+	return results
+}
+
+// deletesWord reports whether a key deletes a whole word: ctrl+w, or
+// backspace with ctrl or alt. Terminals send backspace as ^? and
+// ctrl+backspace as ^H, only the Windows console sends ^H for both and
+// reports the modifiers instead.
+func (t *Test) deletesWord(key *tcell.EventKey) bool {
+	switch {
+	case key.Key() == tcell.KeyCtrlW:
+		return true
+	case key.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0:
+		return true
+	case key.Key() == tcell.KeyBackspace:
+		return runtime.GOOS != "windows" || sessionOf(t.screen).remote
+	}
+	return false
+}
+
+// deleteChar removes the last typed character and reports whether the
+// confidence mode allowed it.
+func (t *Test) deleteChar() bool {
+	if len(t.typedTxt) == 0 || t.prefs().Confidence == CONFIDENCE_MAX {
+		return false
+	}
+
+	if t.typedTxt[len(t.typedTxt)-1] == ' ' {
+		if !t.canReenterWord() {
+			return false
+		}
+		t.typedWords -= 1
+		// drop the padding of skipped letters so the caret lands after
+		// what was actually typed
+		t.typedTxt = strings.TrimRight(t.typedTxt[:len(t.typedTxt)-1], WRONG_CHAR)
+		return true
+	}
+
+	t.typedTxt = t.typedTxt[:len(t.typedTxt)-1]
+	return true
+}
+
+// deleteWord removes the word under the caret, or the previous word when
+// the caret is at the start of one.
+func (t *Test) deleteWord() {
+	if t.prefs().Confidence == CONFIDENCE_MAX {
+		return
+	}
+
+	if _, typed := t.currentWord(); typed == "" && !t.deleteChar() {
+		return
+	}
+	_, typed := t.currentWord()
+	t.typedTxt = t.typedTxt[:len(t.typedTxt)-len(typed)]
+}
+
+// canReenterWord reports whether backspacing over the last space into the
+// previous word is allowed.
+func (t *Test) canReenterWord() bool {
+	if t.prefs().Confidence != CONFIDENCE_KEEP_CORRECT {
+		return true
+	}
+
+	return !t.previousWordCorrect()
+}
+
+// previousWordCorrect reports whether the last word finished with a space
+// matches the text.
+func (t *Test) previousWordCorrect() bool {
+	correctWords := strings.Split(t.txt, " ")
+	typedWords := strings.Split(t.typedTxt, " ")
+	i := len(typedWords) - 2
+	return i >= 0 && i < len(correctWords) && correctWords[i] == typedWords[i]
+}
+
+// correctChars counts the typed characters matching the text along with
+// the spaces between the words typed so far.
+func (t *Test) correctChars() int {
+	correctWords := strings.Split(t.txt, " ")
+	typedWords := strings.Split(t.typedTxt, " ")
+
+	correct := 0
+	for i := 0; i < len(correctWords) && i < len(typedWords); i++ {
+		for j := 0; j < len(correctWords[i]) && j < len(typedWords[i]); j++ {
+			if correctWords[i][j] == typedWords[i][j] {
+				correct += 1
+			}
+		}
+	}
+
+	spaces := len(typedWords) - 1
+	if spaces > len(correctWords)-1 {
+		spaces = len(correctWords) - 1
+	}
+
+	return correct + spaces
+}
+
+// currentWord returns the target word under the caret and what has been
+// typed of it so far.
+func (t *Test) currentWord() (string, string) {
+	correctWords := strings.Split(t.txt, " ")
+	typedWords := strings.Split(t.typedTxt, " ")
+
+	i := len(typedWords) - 1
+	if i >= len(correctWords) {
+		return "", typedWords[i]
+	}
+	return correctWords[i], typedWords[i]
+}
+
+// keyCorrect reports whether r is the next character of the text. A space
+// is correct once every letter of the current word has been typed.
+func (t *Test) keyCorrect(r rune) bool {
+	target, typed := t.currentWord()
+	if r == ' ' {
+		return len(typed) == len(target)
+	}
+	return len(typed) < len(target) && rune(target[len(typed)]) == r
+}
+
+// recordKey adds the press of the next expected character, along with the
+// time since the previous key, to the test's key stats. Presses inside a
+// word also count towards the bigram they finish.
+func (t *Test) recordKey(correct bool) {
+	now := t.clock.Elapsed()
+	latency := now - t.lastKeyAt
+	if t.keystrokes == 0 {
+		latency = 0
+	}
+	t.lastKeyAt = now
+
+	target, typed := t.currentWord()
+	if len(typed) >= len(target) {
+		t.keyStats.Record(" ", correct, latency)
+		return
+	}
+
+	t.keyStats.Record(target[len(typed):len(typed)+1], correct, latency)
+	if len(typed) > 0 {
+		t.keyStats.Record(target[len(typed)-1:len(typed)+1], correct, latency)
+	}
+}
+
+// accepts reports whether a key is added to the typed text under the stop
+// on error and strict space rules.
+func (t *Test) accepts(r rune, correct bool) bool {
+	if r != ' ' {
+		return correct || t.prefs().StopOnError != STOP_ON_LETTER
+	}
+
+	target, typed := t.currentWord()
+	if t.prefs().StrictSpace && len(typed) < len(target) {
+		return false
+	}
+	return typed == target || t.prefs().StopOnError != STOP_ON_WORD
+}
+
+func (t *Test) beep(correct bool) {
+	if t.prefs().Sound == SOUND_ALL || (t.prefs().Sound == SOUND_ERRORS && !correct) {
+		_ = t.screen.Beep()
+	}
 }
 
 func (t *Test) generateText() {
-	if t.kind == TEST_QUOTE {
-		t.txt = generateQuote(t.config)
-	} else {
+	if t.txt == "" {
+		t.seed = t.config.Seed
+		if t.seed == 0 {
+			t.seed = time.Now().UnixNano()
+		}
+	}
+	r := rand.New(rand.NewSource(t.seed))
+
+	if t.txt == "" && t.kind == TEST_QUOTE {
+		t.txt = generateQuote(r, t.config)
+	} else if t.txt == "" && t.kind == TEST_DRILL {
+		t.txt = generateDrillWords(r, t.config, currentLayout(t.prefs()))
+	} else if t.txt == "" && t.kind == TEST_CURRICULUM {
+		t.txt = generateCurriculumLesson(r, t.config)
+	} else if t.txt == "" && t.kind == TEST_LESSON {
+		t.txt = generateLessonWords(r, t.config, LoadLessonProgress(storeOf(t.screen)))
+	} else if t.txt == "" && t.kind == TEST_PRACTICE {
+		stats, _ := LoadKeyStats(storeOf(t.screen))
+		t.txt = generatePracticeWords(r, t.config, weakTargets(stats))
+	} else if t.txt == "" && t.practice != nil {
+		t.txt = practiceText(r, t.practice)
+		t.config.Words = len(strings.Fields(t.txt))
+	} else if t.txt == "" {
 		if t.config.Words == 0 {
 			t.config.Words = t.config.Duration + t.config.Duration/2
 		}
-		t.txt = generateWords(t.config)
+		t.txt = generateWords(r, t.config)
 	}
 
 	t.words = len(strings.Split(t.txt, " "))
 }
 
-func generateQuote(conf Config) string {
+func generateQuote(r *rand.Rand, conf Config) string {
 	categoryToFile := map[string]string{
 		"short":  shortQuotes,
 		"medium": mediumQuotes,
@@ -251,14 +617,12 @@ func generateQuote(conf Config) string {
 		}
 	}
 
-	selectedQuote := nonEmptyQuotes[rand.Intn(len(nonEmptyQuotes))]
-	selectedQuote = strings.TrimSpace(selectedQuote)
-	return selectedQuote
+	// some quotes have tabs and runs of spaces, they're typed as single spaces
+	selectedQuote := nonEmptyQuotes[r.Intn(len(nonEmptyQuotes))]
+	return strings.Join(strings.Fields(selectedQuote), " ")
 }
 
-func generateWords(conf Config) string {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
+func generateWords(r *rand.Rand, conf Config) string {
 	words := strings.Fields(words)
 	r.Shuffle(len(words), func(i, j int) {
 		words[i], words[j] = words[j], words[i]
@@ -268,13 +632,13 @@ func generateWords(conf Config) string {
 
 	if conf.Punctuation {
 		punctuation := []string{".", ",", "!", "?", ";", ":"}
-		indexes := generateRandomNumbers(conf.Words/PUNCTUATIONS_FACTOR, 0, conf.Words-1)
+		indexes := generateRandomNumbers(r, conf.Words/PUNCTUATIONS_FACTOR, 0, conf.Words-1)
 		for _, i := range indexes {
 			selectedWords[i] += punctuation[r.Intn(len(punctuation))]
 		}
 
 		wrappers := []string{"[]", "()", "{}", `""`, `''`}
-		indexes = generateRandomNumbers(conf.Words/WRAPPER_FACTOR, 0, conf.Words-1)
+		indexes = generateRandomNumbers(r, conf.Words/WRAPPER_FACTOR, 0, conf.Words-1)
 		for _, i := range indexes {
 			wrap := wrappers[r.Intn(len(wrappers))]
 			selectedWords[i] = fmt.Sprintf("%c%s%c", wrap[0], selectedWords[i], wrap[1])
@@ -282,9 +646,9 @@ func generateWords(conf Config) string {
 	}
 
 	if conf.Number {
-		indexes := generateRandomNumbers(conf.Words/NUM_FACTOR, 0, conf.Words-1)
+		indexes := generateRandomNumbers(r, conf.Words/NUM_FACTOR, 0, conf.Words-1)
 		for _, i := range indexes {
-			selectedWords[i] = fmt.Sprintf("%d", rand.Intn(MAX_NUM))
+			selectedWords[i] = fmt.Sprintf("%d", r.Intn(MAX_NUM))
 		}
 	}
 
@@ -293,9 +657,7 @@ func generateWords(conf Config) string {
 	return result
 }
 
-func generateRandomNumbers(n, min, max int) []int {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
+func generateRandomNumbers(r *rand.Rand, n, min, max int) []int {
 	randomNumbers := make([]int, n)
 	for i := 0; i < n; i++ {
 		randomNumbers[i] = r.Intn(max-min+1) + min